@@ -2,64 +2,70 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
+	"searcher-app/internal/config"
 	"searcher-app/internal/database"
+	"searcher-app/internal/events"
 	"searcher-app/internal/handlers"
+	"searcher-app/internal/logging"
+	"searcher-app/internal/metrics"
 	"searcher-app/internal/middleware"
 	"searcher-app/internal/repository"
 	"searcher-app/internal/services"
+	"searcher-app/internal/tracing"
 	"searcher-app/internal/worker"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"golang.org/x/time/rate"
 )
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return fallback
-}
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
 
-func getEnvInt(key string, fallback int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			log.Fatal("Failed to print configuration:", err)
 		}
 	}
-	return fallback
-}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *printConfig {
+		return
+	}
 
-func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(logging.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
-
-	dbConfig := &database.DatabaseConfig{
-		Host:           getEnv("DB_HOST", "127.0.0.1"),
-		Port:           getEnvInt("DB_PORT", 3306),
-		Username:       getEnv("DB_USER", "analyzer_user"),
-		Password:       getEnv("DB_PASSWORD", "analyzer_pass"),
-		Database:       getEnv("DB_NAME", "website_analyzer"),
-		ConnectTimeout: 30 * time.Second,
-		ReadTimeout:    60 * time.Second,
-		WriteTimeout:   60 * time.Second,
-		Charset:        "utf8mb4",
-		ParseTime:      true,
-		Location:       "Local",
+	})))
+
+	shutdownTracing, err := tracing.Setup(ctx, "searcher-api", logger)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
 	}
 
+	dbConfig := &cfg.Database
+
 	db, err := database.NewDatabase(dbConfig, logger)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
@@ -69,35 +75,108 @@ func main() {
 	log.Println("Enhanced database connection established successfully")
 
 	urlRepo := repository.NewMySQLURLRepository(db.DB)
+	deadLetterRepo := repository.NewMySQLDeadLetterRepository(db.DB)
+	cleanupRepo := repository.NewMySQLCleanupRepository(db.DB)
+	jobQueueRepo := repository.NewMySQLJobQueueRepository(db.DB)
+	jobEventRepo := repository.NewMySQLJobEventRepository(db.DB)
+
+	priorityPool := worker.NewPriorityWorkerPool(
+		cfg.Worker.Count,
+		cfg.Worker.QueueSize,
+		logger,
+	)
+	workerPool := priorityPool.WorkerPool
+	workerPool.SetConcurrencyLimit(worker.JobTypeCrawlURL, cfg.Worker.MaxCrawlJobs)
+	workerPool.SetConcurrencyLimit(worker.JobTypeAnalyzeURL, cfg.Worker.MaxAnalyzeJobs)
+	workerPool.SetConcurrencyLimit(worker.JobTypeCleanup, cfg.Worker.MaxCleanupJobs)
 
-	workerPool := worker.NewWorkerPool(10, 100, logger)
-	workerPool.Start(ctx)
-	defer workerPool.Stop()
+	// The pool gets its own context so running jobs are not cancelled by the
+	// shutdown signal and can finish while the pool drains.
+	poolCtx, stopPool := context.WithCancel(context.Background())
+	defer stopPool()
+	workerPool.Start(poolCtx)
 
-	log.Println("Worker pool initialized with 10 workers")
+	log.Printf("Priority worker pool initialized with %d workers", workerPool.WorkerCount())
 
-	crawlerConfig := &services.CrawlerConfig{
-		MaxConcurrentCrawls: 10,
-		RequestTimeout:      30 * time.Second,
-		UserAgent:           "WebsiteAnalyzer/1.0",
-		MaxRedirects:        5,
-		MaxResponseSize:     10 * 1024 * 1024,
-		RetryAttempts:       3,
-		RetryDelay:          1 * time.Second,
+	jobQueue := services.NewDurableJobQueue(jobQueueRepo)
+	recoverTypes := []worker.JobType{worker.JobTypeAnalyzeURL, worker.JobTypeCrawlURL, worker.JobTypeCleanup}
+	if cfg.Worker.Mode == config.WorkerModeDistributed {
+		workerPool.ForwardJobs(jobQueue, worker.JobTypeAnalyzeURL, worker.JobTypeCrawlURL)
+		recoverTypes = []worker.JobType{worker.JobTypeCleanup}
+		log.Println("Analysis and crawl jobs are forwarded to the durable job queue")
 	}
 
-	crawlerService := services.NewCrawlerService(urlRepo, workerPool, crawlerConfig, logger)
+	crawlerConfig := &cfg.Crawler
+
+	eventBus := events.NewBus(logger)
+	// Partially filled batches are submitted every flush interval, and at
+	// drain their jobs are handed back with the other unfinished jobs.
+	batchPool := worker.WrapBatchWorkerPool(workerPool, cfg.Worker.BatchSize)
+	batchPool.StartFlusher(ctx, cfg.Worker.BatchFlushInterval)
+
+	crawlerService := services.NewCrawlerService(urlRepo, workerPool, batchPool, eventBus, crawlerConfig, logger)
+	deadLetterService := services.NewDeadLetterService(deadLetterRepo, workerPool, logger)
+
+	cleanupConfig := &cfg.Cleanup
+	cleanupService := services.NewCleanupService(cleanupRepo, workerPool, cleanupConfig, logger)
+	cleanupService.Start(ctx)
+
+	reaperConfig := &cfg.Reaper
+	stuckURLReaper := services.NewStuckURLReaper(crawlerService, urlRepo, eventBus, reaperConfig, logger)
+	stuckURLReaper.Start(ctx)
+
+	recoveryService := services.NewJobRecoveryService(urlRepo, jobQueue, workerPool, logger)
+	go func() {
+		if _, err := recoveryService.RecoverPersisted(ctx, recoverTypes...); err != nil {
+			log.Printf("Failed to recover jobs persisted at last shutdown: %v", err)
+		}
+	}()
 
 	wsHandler := handlers.NewWebSocketHandler()
 	go wsHandler.Run()
+	eventBus.Subscribe(wsHandler.HandleEvent)
 
-	urlHandler := handlers.NewURLHandler(crawlerService, wsHandler)
+	if cfg.Worker.Mode == config.WorkerModeDistributed {
+		// Worker processes store the events their jobs publish; relaying them
+		// here reaches WebSocket clients and the stuck URL reaper.
+		eventRelay := services.NewEventRelay(jobEventRepo, eventBus, services.EventRelayConfig{
+			PollInterval: cfg.Worker.EventPollInterval,
+			Retention:    cfg.Worker.EventRetention,
+		}, logger)
+		eventRelay.Start(ctx)
+	}
+
+	urlHandler := handlers.NewURLHandler(crawlerService, eventBus, logger)
+	jobHandler := handlers.NewJobHandler(workerPool, crawlerService)
+	deadLetterHandler := handlers.NewDeadLetterHandler(deadLetterService)
+	adminHandler := handlers.NewAdminHandler(workerPool, cleanupService)
+	healthHandler := handlers.NewHealthHandler(db.DB, workerPool, wsHandler, cfg.Server.HealthCheckTimeout)
+
+	prometheus.MustRegister(
+		metrics.NewPoolCollector(workerPool),
+		metrics.NewDatabaseCollector(db),
+		metrics.NewWebSocketClientsGauge(wsHandler.ClientCount),
+	)
 
 	r := gin.New()
 
-	r.Use(gin.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.AccessLog(logger))
 	r.Use(gin.Recovery())
-	r.Use(middleware.DefaultCORSMiddleware())
+	r.Use(middleware.Metrics())
+	r.Use(otelgin.Middleware("searcher-api", otelgin.WithFilter(func(req *http.Request) bool {
+		switch req.URL.Path {
+		case "/metrics", "/health", "/healthz", "/readyz":
+			return false
+		}
+		return true
+	})))
+
+	corsConfig := middleware.DefaultCORSConfig()
+	corsConfig.AllowOrigins = cfg.CORS.AllowOrigins
+	corsConfig.AllowCredentials = cfg.CORS.AllowCredentials
+	corsConfig.MaxAge = int(cfg.CORS.MaxAge / time.Second)
+	r.Use(middleware.CORSMiddleware(corsConfig))
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -107,42 +186,93 @@ func main() {
 		})
 	})
 
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	r.GET("/ws", wsHandler.HandleWebSocket)
 
 	api := r.Group("/api")
-	api.Use(middleware.APIKeyAuth())
+	if cfg.RateLimit.Enabled {
+		rateLimiter := middleware.NewRateLimiter(rate.Limit(cfg.RateLimit.RequestsPerSecond), cfg.RateLimit.Burst, cfg.RateLimit.CleanupInterval)
+		api.Use(rateLimiter.RateLimitMiddleware())
+	}
+	api.Use(middleware.NewAPIKeyAuth(cfg.Auth.APIKey))
 	{
 		api.GET("/urls", urlHandler.GetURLs)
+		api.GET("/urls/duplicates", urlHandler.GetDuplicates)
 		api.GET("/urls/:id", urlHandler.GetURL)
 		api.GET("/urls/:id/broken-links", urlHandler.GetBrokenLinks)
+		api.GET("/urls/:id/links", urlHandler.GetLinks)
 		api.POST("/urls", urlHandler.CreateURL)
 		api.PUT("/urls/:id/analyze", urlHandler.AnalyzeURL)
+		api.POST("/urls/:id/cancel", urlHandler.CancelAnalysis)
 		api.DELETE("/urls/:id", urlHandler.DeleteURL)
 		api.POST("/urls/bulk-analyze", urlHandler.BulkAnalyze)
 		api.POST("/urls/bulk-delete", urlHandler.BulkDelete)
+
+		api.GET("/sites/:host/link-analysis", urlHandler.GetSiteLinkAnalysis)
+
+		api.GET("/jobs", jobHandler.ListJobs)
+		api.GET("/jobs/:id", jobHandler.GetJob)
+		api.DELETE("/jobs/:id", jobHandler.CancelJob)
+
+		api.GET("/dead-letters", deadLetterHandler.ListDeadLetters)
+		api.GET("/dead-letters/:id", deadLetterHandler.GetDeadLetter)
+		api.POST("/dead-letters/:id/replay", deadLetterHandler.ReplayDeadLetter)
+		api.POST("/dead-letters/replay", deadLetterHandler.ReplayDeadLetters)
+		api.DELETE("/dead-letters/:id", deadLetterHandler.DeleteDeadLetter)
+		api.DELETE("/dead-letters", deadLetterHandler.PurgeDeadLetters)
+
+		api.GET("/admin/workers", adminHandler.GetWorkerPool)
+		api.PUT("/admin/workers", adminHandler.UpdateWorkerPool)
+		api.POST("/admin/cleanup", adminHandler.RunCleanup)
 	}
 
-	port := getEnv("PORT", "8080")
+	port := cfg.Server.Port
 
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: r,
 	}
 
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
+
 		sigterm := make(chan os.Signal, 1)
 		signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
 		<-sigterm
 
 		log.Println("Shutting down server...")
-		cancel()
 
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 		defer shutdownCancel()
 
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			log.Printf("Server forced to shutdown: %v", err)
 		}
+
+		// The steps after the drain get their own deadlines, so a slow HTTP
+		// shutdown and a full drain cannot leave them without time.
+		unfinished := workerPool.Drain(cfg.Worker.DrainTimeout)
+		persistCtx, persistCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := recoveryService.PersistUnfinished(persistCtx, unfinished); err != nil {
+			log.Printf("Failed to persist unfinished jobs: %v", err)
+		}
+		persistCancel()
+		stopPool()
+		workerPool.Stop()
+
+		wsHandler.Close()
+
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+		flushCancel()
+		cancel()
 	}()
 
 	log.Printf("Server starting on port %s", port)
@@ -151,5 +281,6 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 
+	<-shutdownDone
 	log.Println("Server stopped")
 }
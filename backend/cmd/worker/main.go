@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"searcher-app/internal/config"
+	"searcher-app/internal/database"
+	"searcher-app/internal/events"
+	"searcher-app/internal/logging"
+	"searcher-app/internal/metrics"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/services"
+	"searcher-app/internal/tracing"
+	"searcher-app/internal/worker"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			log.Fatal("Failed to print configuration:", err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *printConfig {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.New(logging.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	shutdownTracing, err := tracing.Setup(ctx, "searcher-worker", logger)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
+	dbConfig := &cfg.Database
+
+	db, err := database.NewDatabase(dbConfig, logger)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	urlRepo := repository.NewMySQLURLRepository(db.DB)
+	deadLetterRepo := repository.NewMySQLDeadLetterRepository(db.DB)
+	jobQueueRepo := repository.NewMySQLJobQueueRepository(db.DB)
+	jobEventRepo := repository.NewMySQLJobEventRepository(db.DB)
+
+	priorityPool := worker.NewPriorityWorkerPool(
+		cfg.Worker.Count,
+		cfg.Worker.QueueSize,
+		logger,
+	)
+	workerPool := priorityPool.WorkerPool
+	workerPool.SetConcurrencyLimit(worker.JobTypeCrawlURL, cfg.Worker.MaxCrawlJobs)
+	workerPool.SetConcurrencyLimit(worker.JobTypeAnalyzeURL, cfg.Worker.MaxAnalyzeJobs)
+
+	crawlerConfig := &cfg.Crawler
+
+	// Events are stored for the API process to relay to its WebSocket clients.
+	eventBus := events.NewBus(logger)
+	eventOutbox := services.NewEventOutbox(jobEventRepo, eventBus, logger)
+	services.NewCrawlerService(urlRepo, workerPool, worker.WrapBatchWorkerPool(workerPool, cfg.Worker.BatchSize), eventBus, crawlerConfig, logger)
+	services.NewDeadLetterService(deadLetterRepo, workerPool, logger)
+
+	jobQueue := services.NewDurableJobQueue(jobQueueRepo)
+	recoveryService := services.NewJobRecoveryService(urlRepo, jobQueue, workerPool, logger)
+
+	workerID := cfg.Worker.ID
+	if workerID == "" {
+		workerID = defaultWorkerID()
+	}
+
+	runner := worker.NewRemoteRunner(workerPool, jobQueue, worker.RemoteRunnerConfig{
+		WorkerID:      workerID,
+		JobTypes:      []worker.JobType{worker.JobTypeAnalyzeURL, worker.JobTypeCrawlURL},
+		LeaseDuration: cfg.Worker.LeaseDuration,
+		PollInterval:  cfg.Worker.PollInterval,
+	}, logger)
+
+	// The pool gets its own context so running jobs survive the signal and
+	// can finish while the runner drains.
+	poolCtx, stopPool := context.WithCancel(context.Background())
+	defer stopPool()
+	workerPool.Start(poolCtx)
+
+	var metricsServer *http.Server
+	if metricsPort := cfg.Worker.MetricsPort; metricsPort != "0" {
+		prometheus.MustRegister(
+			metrics.NewPoolCollector(workerPool),
+			metrics.NewDatabaseCollector(db),
+		)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsServer = &http.Server{Addr: ":" + metricsPort, Handler: mux}
+
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics server failed: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		sigterm := make(chan os.Signal, 1)
+		signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
+		<-sigterm
+
+		log.Println("Shutting down worker...")
+		cancel()
+	}()
+
+	log.Printf("Worker connected to %s:%d", dbConfig.Host, dbConfig.Port)
+	runner.Run(ctx)
+	unfinished := runner.Drain(cfg.Worker.DrainTimeout)
+
+	resetCtx, resetCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	recoveryService.ResetInterrupted(resetCtx, unfinished)
+	resetCancel()
+
+	stopPool()
+	workerPool.Stop()
+	eventOutbox.Close()
+
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	flushCancel()
+
+	log.Println("Worker stopped")
+}
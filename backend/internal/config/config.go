@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"searcher-app/internal/database"
+	"searcher-app/internal/services"
+	"searcher-app/internal/worker"
+)
+
+// Config holds every setting of the API and worker processes. Each section is
+// read from the environment variables named in its envconfig tags, falling
+// back to the matching section of an optional config file and then to the
+// default tags.
+type Config struct {
+	Server    ServerConfig            `config:"server"`
+	Database  database.DatabaseConfig `config:"database"`
+	Crawler   services.CrawlerConfig  `config:"crawler"`
+	Worker    WorkerConfig            `config:"worker"`
+	Cleanup   services.CleanupConfig  `config:"cleanup"`
+	Reaper    services.ReaperConfig   `config:"reaper"`
+	CORS      CORSConfig              `config:"cors"`
+	Auth      AuthConfig              `config:"auth"`
+	RateLimit RateLimitConfig         `config:"rate_limit"`
+}
+
+type ServerConfig struct {
+	Port               string        `envconfig:"PORT" default:"8080"`
+	ShutdownTimeout    time.Duration `envconfig:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"`
+	HealthCheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+}
+
+type WorkerConfig struct {
+	Count          int           `envconfig:"WORKER_COUNT" default:"10"`
+	QueueSize      int           `envconfig:"WORKER_QUEUE_SIZE" default:"100"`
+	MaxCrawlJobs   int           `envconfig:"WORKER_MAX_CRAWL_JOBS" default:"3"`
+	MaxAnalyzeJobs int           `envconfig:"WORKER_MAX_ANALYZE_JOBS" default:"10"`
+	MaxCleanupJobs int           `envconfig:"WORKER_MAX_CLEANUP_JOBS" default:"1"`
+	Mode           string        `envconfig:"WORKER_MODE" default:"local"`
+	DrainTimeout   time.Duration `envconfig:"WORKER_DRAIN_TIMEOUT" default:"25s"`
+
+	BatchSize          int           `envconfig:"WORKER_BATCH_SIZE" default:"50"`
+	BatchFlushInterval time.Duration `envconfig:"WORKER_BATCH_FLUSH_INTERVAL" default:"5s"`
+
+	// Settings of cmd/worker processes.
+	ID            string        `envconfig:"WORKER_ID" default:""`
+	LeaseDuration time.Duration `envconfig:"WORKER_LEASE_DURATION" default:"1m"`
+	PollInterval  time.Duration `envconfig:"WORKER_POLL_INTERVAL" default:"1s"`
+	MetricsPort   string        `envconfig:"WORKER_METRICS_PORT" default:"9091"`
+
+	// How the API process relays events published by cmd/worker processes.
+	EventPollInterval time.Duration `envconfig:"WORKER_EVENT_POLL_INTERVAL" default:"1s"`
+	EventRetention    time.Duration `envconfig:"WORKER_EVENT_RETENTION" default:"1h"`
+}
+
+const (
+	WorkerModeLocal       = "local"
+	WorkerModeDistributed = "distributed"
+)
+
+type CORSConfig struct {
+	AllowOrigins     []string      `envconfig:"CORS_ALLOW_ORIGINS" default:"http://localhost:3000,http://localhost:3001,http://localhost:5173,http://127.0.0.1:3000,http://127.0.0.1:3001,http://127.0.0.1:5173"`
+	AllowCredentials bool          `envconfig:"CORS_ALLOW_CREDENTIALS" default:"true"`
+	MaxAge           time.Duration `envconfig:"CORS_MAX_AGE" default:"5m"`
+}
+
+type AuthConfig struct {
+	APIKey string `envconfig:"API_KEY" default:"dev-api-key-2024" secret:"true"`
+}
+
+type RateLimitConfig struct {
+	Enabled           bool          `envconfig:"RATE_LIMIT_ENABLED" default:"false"`
+	RequestsPerSecond float64       `envconfig:"RATE_LIMIT_RPS" default:"10"`
+	Burst             int           `envconfig:"RATE_LIMIT_BURST" default:"20"`
+	CleanupInterval   time.Duration `envconfig:"RATE_LIMIT_CLEANUP_INTERVAL" default:"5m"`
+}
+
+// Load builds the configuration from defaults, the config file at path, if
+// any, and the environment, in increasing order of precedence. It does not
+// validate the result.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if err := applyDefaults(cfg); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyFile(cfg, values); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "PORT must be a port number, got %q", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT must be positive")
+	check(c.Server.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+
+	db := c.Database
+	check(db.Host != "", "DB_HOST is required")
+	check(db.Port > 0 && db.Port <= 65535, "DB_PORT must be between 1 and 65535")
+	check(db.Username != "", "DB_USER is required")
+	check(db.Database != "", "DB_NAME is required")
+	check(db.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(db.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", db.MaxIdleConns, db.MaxOpenConns)
+	check(db.ConnectTimeout > 0 && db.ReadTimeout > 0 && db.WriteTimeout > 0,
+		"DB_CONNECT_TIMEOUT, DB_READ_TIMEOUT and DB_WRITE_TIMEOUT must be positive")
+	if err := db.ValidateSSL(); err != nil {
+		errs = append(errs, err)
+	}
+
+	cr := c.Crawler
+	check(cr.MaxConcurrentCrawls >= 0, "CRAWLER_MAX_CONCURRENT must not be negative")
+	check(cr.RequestTimeout > 0, "CRAWLER_REQUEST_TIMEOUT must be positive")
+	check(cr.MaxRedirects >= 0, "CRAWLER_MAX_REDIRECTS must not be negative")
+	check(cr.MaxResponseSize > 0, "CRAWLER_MAX_RESPONSE_SIZE must be positive")
+	check(cr.RetryAttempts >= 0, "CRAWLER_RETRY_ATTEMPTS must not be negative")
+	check(cr.RetryDelay >= 0, "CRAWLER_RETRY_DELAY must not be negative")
+	check(cr.AnalyzeTimeout > 0 && cr.CrawlTimeout > 0,
+		"CRAWLER_ANALYZE_TIMEOUT and CRAWLER_CRAWL_TIMEOUT must be positive")
+	check(cr.HeartbeatTimeout >= 0, "CRAWLER_HEARTBEAT_TIMEOUT must not be negative")
+	check(cr.StuckAfter > 0, "CRAWLER_STUCK_AFTER must be positive")
+
+	w := c.Worker
+	check(w.Count >= 1 && w.Count <= worker.MaxWorkers, "WORKER_COUNT must be between 1 and %d", worker.MaxWorkers)
+	check(w.QueueSize >= 1, "WORKER_QUEUE_SIZE must be at least 1")
+	check(w.MaxCrawlJobs >= 0 && w.MaxAnalyzeJobs >= 0 && w.MaxCleanupJobs >= 0,
+		"WORKER_MAX_*_JOBS must not be negative")
+	check(w.Mode == WorkerModeLocal || w.Mode == WorkerModeDistributed,
+		"WORKER_MODE must be %q or %q, got %q", WorkerModeLocal, WorkerModeDistributed, w.Mode)
+	check(w.DrainTimeout >= 0, "WORKER_DRAIN_TIMEOUT must not be negative")
+	check(w.BatchSize >= 1, "WORKER_BATCH_SIZE must be at least 1")
+	check(w.BatchFlushInterval > 0, "WORKER_BATCH_FLUSH_INTERVAL must be positive")
+	check(w.LeaseDuration > 0, "WORKER_LEASE_DURATION must be positive")
+	check(w.PollInterval > 0, "WORKER_POLL_INTERVAL must be positive")
+	check(w.EventPollInterval > 0, "WORKER_EVENT_POLL_INTERVAL must be positive")
+	check(w.EventRetention > 0, "WORKER_EVENT_RETENTION must be positive")
+	check(w.MetricsPort == "0" || validPort(w.MetricsPort),
+		"WORKER_METRICS_PORT must be a port number or 0, got %q", w.MetricsPort)
+
+	check(c.Cleanup.Interval >= 0, "CLEANUP_INTERVAL must not be negative")
+	check(c.Cleanup.AnalysisRetentionDays >= 0 && c.Cleanup.ErrorRetentionDays >= 0 && c.Cleanup.JobRetentionDays >= 0,
+		"CLEANUP_*_RETENTION_DAYS must not be negative")
+	check(c.Cleanup.MaxBrokenLinksPerURL >= 0, "CLEANUP_MAX_BROKEN_LINKS_PER_URL must not be negative")
+
+	check(c.Reaper.Interval >= 0, "REAPER_INTERVAL must not be negative")
+	check(c.Reaper.MaxRequeues >= 0, "REAPER_MAX_REQUEUES must not be negative")
+	check(c.Reaper.BatchSize >= 1, "REAPER_BATCH_SIZE must be at least 1")
+
+	check(len(c.CORS.AllowOrigins) > 0, "CORS_ALLOW_ORIGINS must list at least one origin")
+	for _, origin := range c.CORS.AllowOrigins {
+		check(!(origin == "*" && c.CORS.AllowCredentials),
+			"CORS_ALLOW_ORIGINS must not contain \"*\" while CORS_ALLOW_CREDENTIALS is enabled")
+	}
+	check(c.CORS.MaxAge >= 0, "CORS_MAX_AGE must not be negative")
+
+	check(c.Auth.APIKey != "", "API_KEY is required")
+
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.RequestsPerSecond > 0, "RATE_LIMIT_RPS must be positive")
+		check(c.RateLimit.Burst >= 1, "RATE_LIMIT_BURST must be at least 1")
+		check(c.RateLimit.CleanupInterval > 0, "RATE_LIMIT_CLEANUP_INTERVAL must be positive")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Dump writes the configuration as YAML in the config file layout, each
+// setting annotated with its environment variable. Secrets are redacted.
+func (c *Config) Dump(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+
+	for _, f := range fields(c) {
+		section, ok := sections[f.section]
+		if !ok {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			sections[f.section] = section
+			root.Content = append(root.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: f.section}, section)
+		}
+
+		var value interface{} = f.value.Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		if f.secret && !f.value.IsZero() {
+			value = redacted
+		}
+
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", f.env, err)
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: f.key, LineComment: f.env}
+		if valueNode.Kind != yaml.ScalarNode {
+			// Comments on block values would land after the first item.
+			keyNode.LineComment = ""
+			keyNode.HeadComment = f.env
+		}
+		section.Content = append(section.Content, keyNode, valueNode)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type field struct {
+	section string
+	key     string
+	env     string
+	def     string
+	secret  bool
+	value   reflect.Value
+}
+
+// fields lists every setting of cfg in declaration order. Only struct fields
+// with an envconfig tag are settings.
+func fields(cfg *Config) []field {
+	var out []field
+
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i).Tag.Get("config")
+		sectionValue := root.Field(i)
+
+		for j := 0; j < sectionValue.NumField(); j++ {
+			sf := sectionValue.Type().Field(j)
+			env, ok := sf.Tag.Lookup("envconfig")
+			if !ok {
+				continue
+			}
+			out = append(out, field{
+				section: section,
+				key:     fileKey(sf.Name),
+				env:     env,
+				def:     sf.Tag.Get("default"),
+				secret:  sf.Tag.Get("secret") == "true",
+				value:   sectionValue.Field(j),
+			})
+		}
+	}
+
+	return out
+}
+
+// fileKey turns a Go field name into its config file key, keeping acronyms
+// together: MaxOpenConns becomes max_open_conns and SSLRootCA ssl_root_ca.
+func fileKey(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func applyDefaults(cfg *Config) error {
+	for _, f := range fields(cfg) {
+		if err := setString(f.value, f.def); err != nil {
+			return fmt.Errorf("invalid default %q for %s: %w", f.def, f.env, err)
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides settings whose variable is set. Empty variables count as
+// unset, as they did when the mains read the environment directly.
+func applyEnv(cfg *Config) error {
+	for _, f := range fields(cfg) {
+		raw := os.Getenv(f.env)
+		if raw == "" {
+			continue
+		}
+		if err := setString(f.value, raw); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", raw, f.env, err)
+		}
+	}
+	return nil
+}
+
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+func applyFile(cfg *Config, values map[string]interface{}) error {
+	bySection := make(map[string]map[string]field)
+	for _, f := range fields(cfg) {
+		if bySection[f.section] == nil {
+			bySection[f.section] = make(map[string]field)
+		}
+		bySection[f.section][f.key] = f
+	}
+
+	for section, raw := range values {
+		known, ok := bySection[section]
+		if !ok {
+			return fmt.Errorf("unknown section %q", section)
+		}
+		if raw == nil {
+			continue
+		}
+		settings, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("section %q must be a mapping", section)
+		}
+
+		for key, value := range settings {
+			f, ok := known[key]
+			if !ok {
+				return fmt.Errorf("unknown setting %s.%s", section, key)
+			}
+			if err := setFileValue(f.value, value); err != nil {
+				return fmt.Errorf("invalid value for %s.%s: %w", section, key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func setFileValue(v reflect.Value, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if v.Kind() != reflect.Slice {
+			return fmt.Errorf("expected a single value, got a list")
+		}
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("list items must be strings, got %v", item)
+			}
+			items = append(items, s)
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	case string:
+		return setString(v, value)
+	case bool:
+		return setString(v, strconv.FormatBool(value))
+	case int:
+		return setString(v, strconv.Itoa(value))
+	case int64:
+		return setString(v, strconv.FormatInt(value, 10))
+	case float64:
+		return setString(v, strconv.FormatFloat(value, 'f', -1, 64))
+	default:
+		return fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+func setString(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestFileKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Port", "port"},
+		{"MaxOpenConns", "max_open_conns"},
+		{"SSLMode", "ssl_mode"},
+		{"SSLRootCA", "ssl_root_ca"},
+		{"APIKey", "api_key"},
+		{"ID", "id"},
+		{"MaxConcurrentCrawls", "max_concurrent_crawls"},
+		{"RecheckLinksOnNotModified", "recheck_links_on_not_modified"},
+		{"HTTP2Enabled", "http2_enabled"},
+		{"Retry5xx", "retry5xx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileKey(tt.name); got != tt.want {
+				t.Errorf("fileKey(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+server:
+  port: "9000"
+worker:
+  count: 4
+  queue_size: 50
+`)
+	t.Setenv("WORKER_COUNT", "6")
+	// Empty variables count as unset.
+	t.Setenv("WORKER_QUEUE_SIZE", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Server.Port != "9000" {
+		t.Errorf("Server.Port = %q, want the file's 9000", cfg.Server.Port)
+	}
+	if cfg.Worker.Count != 6 {
+		t.Errorf("Worker.Count = %d, want the environment's 6", cfg.Worker.Count)
+	}
+	if cfg.Worker.QueueSize != 50 {
+		t.Errorf("Worker.QueueSize = %d, want the file's 50", cfg.Worker.QueueSize)
+	}
+	if cfg.Worker.MaxCrawlJobs != 3 {
+		t.Errorf("Worker.MaxCrawlJobs = %d, want the default 3", cfg.Worker.MaxCrawlJobs)
+	}
+	if cfg.Server.ShutdownTimeout != 30*time.Second {
+		t.Errorf("Server.ShutdownTimeout = %v, want the default 30s", cfg.Server.ShutdownTimeout)
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://a.example, https://b.example,")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() on the defaults: %v", err)
+	}
+
+	want := []string{"https://a.example", "https://b.example"}
+	if !reflect.DeepEqual(cfg.CORS.AllowOrigins, want) {
+		t.Errorf("CORS.AllowOrigins = %q, want %q", cfg.CORS.AllowOrigins, want)
+	}
+}
+
+// TestLoadFileFormats checks that both decoders' value types reach every
+// kind of setting: YAML decodes integers as int, TOML as int64.
+func TestLoadFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+database:
+  port: 3307
+  parse_time: false
+crawler:
+  max_response_size: 2048
+  request_timeout: 45s
+rate_limit:
+  requests_per_second: 2.5
+  burst: 5
+cors:
+  allow_origins:
+    - https://a.example
+    - https://b.example
+`,
+		},
+		{
+			name: "yml",
+			file: "config.yml",
+			content: `
+database: {port: 3307, parse_time: false}
+crawler: {max_response_size: 2048, request_timeout: 45s}
+rate_limit: {requests_per_second: 2.5, burst: 5}
+cors: {allow_origins: [https://a.example, https://b.example]}
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+[database]
+port = 3307
+parse_time = false
+
+[crawler]
+max_response_size = 2048
+request_timeout = "45s"
+
+[rate_limit]
+requests_per_second = 2.5
+burst = 5
+
+[cors]
+allow_origins = ["https://a.example", "https://b.example"]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Database.Port != 3307 {
+				t.Errorf("Database.Port = %d, want 3307", cfg.Database.Port)
+			}
+			if cfg.Database.ParseTime {
+				t.Error("Database.ParseTime = true, want false")
+			}
+			if cfg.Crawler.MaxResponseSize != 2048 {
+				t.Errorf("Crawler.MaxResponseSize = %d, want 2048", cfg.Crawler.MaxResponseSize)
+			}
+			if cfg.Crawler.RequestTimeout != 45*time.Second {
+				t.Errorf("Crawler.RequestTimeout = %v, want 45s", cfg.Crawler.RequestTimeout)
+			}
+			if cfg.RateLimit.RequestsPerSecond != 2.5 {
+				t.Errorf("RateLimit.RequestsPerSecond = %v, want 2.5", cfg.RateLimit.RequestsPerSecond)
+			}
+			if cfg.RateLimit.Burst != 5 {
+				t.Errorf("RateLimit.Burst = %d, want 5", cfg.RateLimit.Burst)
+			}
+			want := []string{"https://a.example", "https://b.example"}
+			if !reflect.DeepEqual(cfg.CORS.AllowOrigins, want) {
+				t.Errorf("CORS.AllowOrigins = %q, want %q", cfg.CORS.AllowOrigins, want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unknown section", "config.yaml", "metrics:\n  port: 9090\n", `unknown section "metrics"`},
+		{"unknown setting", "config.yaml", "server:\n  listen: :8080\n", "unknown setting server.listen"},
+		{"unknown toml setting", "config.toml", "[worker]\nthreads = 4\n", "unknown setting worker.threads"},
+		{"environment variable name as key", "config.yaml", "server:\n  PORT: \"9000\"\n", "unknown setting server.PORT"},
+		{"section not a mapping", "config.yaml", "server: 9000\n", `section "server" must be a mapping`},
+		{"invalid number", "config.yaml", "worker:\n  count: many\n", "invalid value for worker.count"},
+		{"invalid duration", "config.toml", "[server]\nshutdown_timeout = \"soon\"\n", "invalid value for server.shutdown_timeout"},
+		{"list for a single value", "config.yaml", "server:\n  port: [\"1\", \"2\"]\n", "expected a single value"},
+		{"list of numbers", "config.yaml", "cors:\n  allow_origins: [1, 2]\n", "list items must be strings"},
+		{"malformed yaml", "config.yaml", "server: [\n", "failed to parse config file"},
+		{"unsupported format", "config.json", "{}", "unsupported config file format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load returned %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidEnvironment(t *testing.T) {
+	t.Setenv("DB_PORT", "mysql")
+
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Errorf("Load returned %v, want an error naming DB_PORT", err)
+	}
+}
+
+func TestDump(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg.Auth.APIKey = "super-secret-key"
+	cfg.Database.Password = ""
+
+	var buf bytes.Buffer
+	if err := cfg.Dump(&buf); err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "super-secret-key") {
+		t.Error("Dump wrote the API key")
+	}
+	for _, want := range []string{
+		"api_key: '" + redacted + "' # API_KEY",
+		`password: "" # DB_PASSWORD`,
+		`port: "8080" # PORT`,
+		"shutdown_timeout: 30s # SERVER_SHUTDOWN_TIMEOUT",
+		"  # CORS_ALLOW_ORIGINS\n  allow_origins:\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Dump output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// The dump is a valid config file that loads back to the same settings,
+	// apart from the redacted secret.
+	loaded, err := Load(writeConfig(t, "dump.yaml", out))
+	if err != nil {
+		t.Fatalf("Load of the dump: %v", err)
+	}
+	if loaded.Auth.APIKey != redacted {
+		t.Errorf("Auth.APIKey = %q after loading the dump, want %q", loaded.Auth.APIKey, redacted)
+	}
+	loaded.Auth.APIKey = cfg.Auth.APIKey
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load of the dump = %+v, want %+v", loaded, cfg)
+	}
+}
@@ -3,18 +3,23 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type DatabaseConfig struct {
 	Host     string `envconfig:"DB_HOST" default:"localhost"`
 	Port     int    `envconfig:"DB_PORT" default:"3306"`
 	Username string `envconfig:"DB_USER" default:"analyzer_user"`
-	Password string `envconfig:"DB_PASSWORD" default:"rootpass123"`
+	Password string `envconfig:"DB_PASSWORD" default:"analyzer_pass" secret:"true"`
 	Database string `envconfig:"DB_NAME" default:"website_analyzer"`
 
 	MaxOpenConns    int           `envconfig:"DB_MAX_OPEN_CONNS" default:"25"`
@@ -41,38 +46,41 @@ func (cfg *DatabaseConfig) DSN() string {
 		cfg.Charset, cfg.ParseTime, cfg.Location,
 		cfg.ConnectTimeout, cfg.ReadTimeout, cfg.WriteTimeout)
 
-	if cfg.SSLMode != "disable" {
-		params += "&tls=" + cfg.SSLMode
-		if cfg.SSLCert != "" {
-			params += "&tls-cert=" + cfg.SSLCert
-		}
-		if cfg.SSLKey != "" {
-			params += "&tls-key=" + cfg.SSLKey
-		}
-		if cfg.SSLRootCA != "" {
-			params += "&tls-ca=" + cfg.SSLRootCA
-		}
+	if cfg.SSLMode != SSLModeDisable {
+		params += "&tls=" + cfg.tlsParam()
 	}
 
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
 		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, params)
 }
 
+// redactedDSN is the DSN with the password masked, safe to log.
+func (cfg *DatabaseConfig) redactedDSN() string {
+	redacted := *cfg
+	if redacted.Password != "" {
+		redacted.Password = "[REDACTED]"
+	}
+	return redacted.DSN()
+}
+
 type Database struct {
 	*sql.DB
 	config *DatabaseConfig
 	logger *slog.Logger
-	stats  *DatabaseStats
+
+	statsMu sync.Mutex
+	stats   *DatabaseStats
 }
 
 type DatabaseStats struct {
-	OpenConnections   int           `json:"open_connections"`
-	InUse             int           `json:"in_use"`
-	Idle              int           `json:"idle"`
-	WaitCount         int64         `json:"wait_count"`
-	WaitDuration      time.Duration `json:"wait_duration"`
-	MaxIdleClosed     int64         `json:"max_idle_closed"`
-	MaxLifetimeClosed int64         `json:"max_lifetime_closed"`
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
 }
 
 func NewDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*Database, error) {
@@ -82,10 +90,25 @@ func NewDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*Database, error) {
 		slog.String("database", cfg.Database),
 		slog.Duration("connect_timeout", cfg.ConnectTimeout))
 
-	dsn := cfg.DSN()
-	logger.Info("DSN generated", slog.String("dsn", dsn))
+	if err := cfg.registerTLS(); err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open("mysql", dsn)
+	dsn := cfg.DSN()
+	logger.Info("DSN generated", slog.String("dsn", cfg.redactedDSN()))
+
+	db, err := otelsql.Open("mysql", dsn,
+		otelsql.WithAttributes(semconv.DBSystemMySQL, semconv.DBNamespace(cfg.Database)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			// Only trace queries made on behalf of a traced request or job,
+			// so pings and idle bookkeeping do not start traces of their own.
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanFromContext(ctx).SpanContext().IsValid()
+			},
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -122,6 +145,25 @@ func NewDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*Database, error) {
 	}, nil
 }
 
+// GetStats refreshes the connection pool statistics and returns a copy.
+func (d *Database) GetStats() DatabaseStats {
+	dbStats := d.DB.Stats()
+
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+
+	d.stats.OpenConnections = dbStats.OpenConnections
+	d.stats.InUse = dbStats.InUse
+	d.stats.Idle = dbStats.Idle
+	d.stats.WaitCount = dbStats.WaitCount
+	d.stats.WaitDuration = dbStats.WaitDuration
+	d.stats.MaxIdleClosed = dbStats.MaxIdleClosed
+	d.stats.MaxLifetimeClosed = dbStats.MaxLifetimeClosed
+	d.stats.MaxOpenConnections = dbStats.MaxOpenConnections
+
+	return *d.stats
+}
+
 func (d *Database) Close() error {
 	d.logger.Info("Closing database connection")
 	return d.DB.Close()
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name the TLS configuration built from DB_SSL_CERT,
+// DB_SSL_KEY and DB_SSL_ROOT_CA is registered under with the driver.
+const tlsConfigName = "searcher-app"
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeTrue       = "true"
+	SSLModeSkipVerify = "skip-verify"
+	SSLModePreferred  = "preferred"
+)
+
+// ValidateSSL checks the SSL settings against what go-sql-driver/mysql
+// supports.
+func (cfg *DatabaseConfig) ValidateSSL() error {
+	switch cfg.SSLMode {
+	case SSLModeDisable, SSLModeTrue, SSLModeSkipVerify, SSLModePreferred:
+	default:
+		return fmt.Errorf("DB_SSL_MODE must be %q, %q, %q or %q, got %q",
+			SSLModeDisable, SSLModeTrue, SSLModeSkipVerify, SSLModePreferred, cfg.SSLMode)
+	}
+
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		return fmt.Errorf("DB_SSL_CERT and DB_SSL_KEY must be set together")
+	}
+	if cfg.customTLS() && cfg.SSLMode == SSLModePreferred {
+		return fmt.Errorf("DB_SSL_MODE %q cannot be combined with DB_SSL_CERT, DB_SSL_KEY or DB_SSL_ROOT_CA", SSLModePreferred)
+	}
+	return nil
+}
+
+func (cfg *DatabaseConfig) customTLS() bool {
+	return cfg.SSLMode != SSLModeDisable && (cfg.SSLCert != "" || cfg.SSLKey != "" || cfg.SSLRootCA != "")
+}
+
+// tlsParam is the value of the DSN tls parameter.
+func (cfg *DatabaseConfig) tlsParam() string {
+	if cfg.customTLS() {
+		return tlsConfigName
+	}
+	return cfg.SSLMode
+}
+
+// registerTLS loads the certificate files and registers the resulting TLS
+// configuration with the driver, so the DSN can refer to it by name.
+func (cfg *DatabaseConfig) registerTLS() error {
+	if !cfg.customTLS() {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.SSLMode == SSLModeSkipVerify,
+	}
+
+	if cfg.SSLRootCA != "" {
+		pem, err := os.ReadFile(cfg.SSLRootCA)
+		if err != nil {
+			return fmt.Errorf("failed to read DB_SSL_ROOT_CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in DB_SSL_ROOT_CA %s", cfg.SSLRootCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return fmt.Errorf("failed to load DB_SSL_CERT and DB_SSL_KEY: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+		return fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	return nil
+}
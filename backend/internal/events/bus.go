@@ -0,0 +1,88 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeStatusUpdate Type = "status_update"
+	TypeJobProgress  Type = "job_progress"
+	TypeBatchDone    Type = "batch_finished"
+)
+
+type Event struct {
+	Type    Type        `json:"type"`
+	URLID   int         `json:"url_id"`
+	Status  string      `json:"status"`
+	JobID   string      `json:"job_id,omitempty"`
+	JobType string      `json:"job_type,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+type Handler func(event Event)
+
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]Handler
+	nextID      int
+	logger      *slog.Logger
+}
+
+func NewBus(logger *slog.Logger) *Bus {
+	return &Bus{
+		subscribers: make(map[int]Handler),
+		logger:      logger,
+	}
+}
+
+func (b *Bus) Subscribe(handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subscribers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+// Publish delivers the event synchronously to every subscriber. Subscribers
+// are expected to hand work off quickly; a panicking subscriber is logged and
+// does not affect the others.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.subscribers))
+	for _, handler := range b.subscribers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.deliver(handler, event)
+	}
+}
+
+func (b *Bus) deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Event subscriber panicked",
+				slog.String("event_type", string(event.Type)),
+				slog.Any("panic", r))
+		}
+	}()
+
+	handler(event)
+}
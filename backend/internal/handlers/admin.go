@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/services"
+	"searcher-app/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	workerPool     *worker.WorkerPool
+	cleanupService services.CleanupService
+}
+
+func NewAdminHandler(workerPool *worker.WorkerPool, cleanupService services.CleanupService) *AdminHandler {
+	return &AdminHandler{
+		workerPool:     workerPool,
+		cleanupService: cleanupService,
+	}
+}
+
+type workerPoolUpdateRequest struct {
+	Workers     *int                   `json:"workers"`
+	Concurrency map[worker.JobType]int `json:"concurrency"`
+}
+
+func (h *AdminHandler) GetWorkerPool(c *gin.Context) {
+	c.JSON(http.StatusOK, h.workerPool.GetStats())
+}
+
+func (h *AdminHandler) UpdateWorkerPool(c *gin.Context) {
+	var req workerPoolUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.Workers == nil && len(req.Concurrency) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Either workers or concurrency must be provided"})
+		return
+	}
+
+	for jobType, limit := range req.Concurrency {
+		if jobType == "" || limit < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Concurrency limits must be non-negative"})
+			return
+		}
+	}
+
+	if req.Workers != nil {
+		if err := h.workerPool.Resize(*req.Workers); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	for jobType, limit := range req.Concurrency {
+		h.workerPool.SetConcurrencyLimit(jobType, limit)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Worker pool updated",
+		Data:    h.workerPool.GetStats(),
+	})
+}
+
+func (h *AdminHandler) RunCleanup(c *gin.Context) {
+	if c.Query("dry_run") == "true" {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+		defer cancel()
+
+		report, err := h.cleanupService.RunCleanup(ctx, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.SuccessResponse{
+			Message: "Cleanup dry run finished",
+			Data:    report,
+		})
+		return
+	}
+
+	jobID, err := h.cleanupService.ScheduleCleanup(false)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Message: "Cleanup queued",
+		Data:    gin.H{"job_id": jobID},
+	})
+}
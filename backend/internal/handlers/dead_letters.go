@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService services.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	jobs, total, err := h.deadLetterService.ListDeadLetters(ctx, repository.DeadLetterFilter{
+		JobType:        c.Query("type"),
+		OnlyUnreplayed: c.Query("unreplayed") == "true",
+		Page:           page,
+		Limit:          limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       jobs,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	})
+}
+
+func (h *DeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	job, err := h.deadLetterService.GetDeadLetter(ctx, id)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *DeadLetterHandler) ReplayDeadLetter(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	jobID, err := h.deadLetterService.ReplayDeadLetter(ctx, id)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Message: "Dead-letter job replayed",
+		Data:    gin.H{"job_id": jobID},
+	})
+}
+
+func (h *DeadLetterHandler) ReplayDeadLetters(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var req models.DeadLetterReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !req.All && len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Either ids or all must be provided"})
+		return
+	}
+
+	jobIDs, err := h.deadLetterService.ReplayDeadLetters(ctx, req)
+	if err != nil && len(jobIDs) == 0 {
+		c.JSON(deadLetterErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	data := gin.H{
+		"count":   len(jobIDs),
+		"job_ids": jobIDs,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Message: fmt.Sprintf("%d dead-letter jobs replayed", len(jobIDs)),
+		Data:    data,
+	})
+}
+
+func (h *DeadLetterHandler) DeleteDeadLetter(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	if err := h.deadLetterService.DeleteDeadLetter(ctx, id); err != nil {
+		c.JSON(deadLetterErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{Message: "Dead-letter job deleted"})
+}
+
+func (h *DeadLetterHandler) PurgeDeadLetters(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	filter := repository.DeadLetterFilter{JobType: c.Query("type")}
+
+	if olderThan := c.Query("older_than"); olderThan != "" {
+		age, err := time.ParseDuration(olderThan)
+		if err != nil || age <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid older_than duration"})
+			return
+		}
+		before := time.Now().Add(-age)
+		filter.FailedBefore = &before
+	}
+
+	purged, err := h.deadLetterService.PurgeDeadLetters(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: fmt.Sprintf("%d dead-letter jobs purged", purged),
+		Data:    gin.H{"purged": purged},
+	})
+}
+
+func deadLetterErrorStatus(err error) int {
+	if errors.Is(err, repository.ErrDeadLetterNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"searcher-app/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A queue filled beyond this share of its capacity counts as saturated.
+const queueSaturationRatio = 0.9
+
+const (
+	healthStatusUp   = "up"
+	healthStatusDown = "down"
+)
+
+type ComponentHealth struct {
+	Status    string      `json:"status"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Timestamp  time.Time                  `json:"timestamp"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+type healthCheck func(ctx context.Context) (interface{}, error)
+
+type HealthHandler struct {
+	db         *sql.DB
+	workerPool *worker.WorkerPool
+	wsHandler  *WebSocketHandler
+	timeout    time.Duration
+}
+
+func NewHealthHandler(db *sql.DB, workerPool *worker.WorkerPool, wsHandler *WebSocketHandler, timeout time.Duration) *HealthHandler {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &HealthHandler{
+		db:         db,
+		workerPool: workerPool,
+		wsHandler:  wsHandler,
+		timeout:    timeout,
+	}
+}
+
+// Liveness only covers in-process components, so a database outage makes the
+// instance unready without getting it restarted.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	h.respond(c, map[string]healthCheck{
+		"worker_pool": h.checkWorkerPoolRunning,
+		"websocket":   h.checkWebSocket,
+	})
+}
+
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	h.respond(c, map[string]healthCheck{
+		"database":    h.checkDatabase,
+		"worker_pool": h.checkWorkerPoolReady,
+		"websocket":   h.checkWebSocket,
+	})
+}
+
+func (h *HealthHandler) respond(c *gin.Context, checks map[string]healthCheck) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	report := HealthReport{
+		Status:     healthStatusUp,
+		Timestamp:  time.Now().UTC(),
+		Components: make(map[string]ComponentHealth, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			details, err := check(ctx)
+			component := ComponentHealth{
+				Status:    healthStatusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				component.Status = healthStatusDown
+				component.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Components[name] = component
+			if err != nil {
+				report.Status = healthStatusDown
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if report.Status != healthStatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) (interface{}, error) {
+	if err := h.db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+	return nil, nil
+}
+
+func (h *HealthHandler) checkWorkerPoolRunning(ctx context.Context) (interface{}, error) {
+	if !h.workerPool.Running() {
+		return nil, errors.New("worker pool is not running")
+	}
+	return nil, nil
+}
+
+func (h *HealthHandler) checkWorkerPoolReady(ctx context.Context) (interface{}, error) {
+	stats := h.workerPool.GetStats()
+	details := gin.H{
+		"workers":        stats.WorkerCount,
+		"busy_workers":   stats.ActiveJobs,
+		"jobs_in_queue":  stats.JobsInQueue,
+		"queue_capacity": stats.QueueCapacity,
+	}
+
+	if !h.workerPool.Running() {
+		return details, errors.New("worker pool is not running")
+	}
+	if h.workerPool.Draining() {
+		return details, worker.ErrPoolDraining
+	}
+	if stats.QueueCapacity > 0 && float64(stats.JobsInQueue) >= float64(stats.QueueCapacity)*queueSaturationRatio {
+		return details, fmt.Errorf("job queue saturated: %d of %d slots used", stats.JobsInQueue, stats.QueueCapacity)
+	}
+	return details, nil
+}
+
+func (h *HealthHandler) checkWebSocket(ctx context.Context) (interface{}, error) {
+	details := gin.H{"clients": h.wsHandler.ClientCount()}
+	if !h.wsHandler.Alive() {
+		return details, errors.New("websocket hub is not running")
+	}
+	return details, nil
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/services"
+	"searcher-app/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	workerPool     *worker.WorkerPool
+	crawlerService services.CrawlerService
+}
+
+func NewJobHandler(workerPool *worker.WorkerPool, crawlerService services.CrawlerService) *JobHandler {
+	return &JobHandler{
+		workerPool:     workerPool,
+		crawlerService: crawlerService,
+	}
+}
+
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	jobs := h.workerPool.ListJobs(worker.JobFilter{
+		State: worker.JobState(c.Query("state")),
+		Type:  worker.JobType(c.Query("type")),
+		Limit: limit,
+	})
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.lookupJob(ctx, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, worker.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// lookupJob finds a job in the pool's registry or, in distributed mode, in
+// the durable queue the pool forwards jobs to.
+func (h *JobHandler) lookupJob(ctx context.Context, id string) (worker.JobRecord, error) {
+	if job, ok := h.workerPool.GetJob(id); ok {
+		return job, nil
+	}
+	return h.workerPool.GetForwardedJob(ctx, id)
+}
+
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	jobID := c.Param("id")
+	if err := h.crawlerService.CancelJob(ctx, jobID); err != nil {
+		c.JSON(jobErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	job, _ := h.lookupJob(ctx, jobID)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Job cancelled",
+		Data:    job,
+	})
+}
+
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, worker.ErrJobFinished):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"context"
+	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"searcher-app/internal/events"
 	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
 	"searcher-app/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -15,16 +18,28 @@ import (
 
 type URLHandler struct {
 	crawlerService services.CrawlerService
-	wsHandler      *WebSocketHandler
+	events         *events.Bus
+	logger         *slog.Logger
 }
 
-func NewURLHandler(crawlerService services.CrawlerService, wsHandler *WebSocketHandler) *URLHandler {
+func NewURLHandler(crawlerService services.CrawlerService, bus *events.Bus, logger *slog.Logger) *URLHandler {
 	return &URLHandler{
 		crawlerService: crawlerService,
-		wsHandler:      wsHandler,
+		events:         bus,
+		logger:         logger,
 	}
 }
 
+// publishStatus announces a status change made by a request on the event bus,
+// where job results and the stuck URL reaper publish theirs.
+func (h *URLHandler) publishStatus(urlID int, status string) {
+	h.events.Publish(events.Event{
+		Type:   events.TypeStatusUpdate,
+		URLID:  urlID,
+		Status: status,
+	})
+}
+
 func (h *URLHandler) GetURLs(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -47,7 +62,29 @@ func (h *URLHandler) GetURLs(c *gin.Context) {
 	default:
 	}
 
-	urls, total, err := h.crawlerService.GetURLs(page, limit, search)
+	filter := repository.URLFilter{
+		Search: search,
+		Status: models.URLStatus(c.Query("status")),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	var urls []models.URL
+	var total int
+	var err error
+	if c.Query("stuck") == "true" {
+		var stuckAfter time.Duration
+		if value := c.Query("stuck_after"); value != "" {
+			stuckAfter, err = time.ParseDuration(value)
+			if err != nil || stuckAfter <= 0 {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid stuck_after duration"})
+				return
+			}
+		}
+		urls, total, err = h.crawlerService.FindStuckURLs(ctx, filter, stuckAfter)
+	} else {
+		urls, total, err = h.crawlerService.GetURLsWithContext(ctx, filter)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
@@ -83,38 +120,17 @@ func (h *URLHandler) CreateURL(c *gin.Context) {
 	default:
 	}
 
-	url, err := h.crawlerService.AddURL(req.URL)
+	url, err := h.crawlerService.AddURLWithContext(ctx, req.URL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	go func() {
-		bgCtx, bgCancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		defer bgCancel()
-
-		h.wsHandler.BroadcastStatusUpdate(url.ID, "processing", nil)
-
-		err := h.crawlerService.AnalyzeURL(url.ID)
-
-		updatedURL, getErr := h.crawlerService.GetURL(url.ID)
-		if getErr != nil {
-			h.wsHandler.BroadcastStatusUpdate(url.ID, "error", map[string]string{"error": getErr.Error()})
-			return
-		}
-
-		if err != nil {
-			h.wsHandler.BroadcastStatusUpdate(url.ID, "error", updatedURL)
-		} else {
-			h.wsHandler.BroadcastStatusUpdate(url.ID, "completed", updatedURL)
-		}
-
-		select {
-		case <-bgCtx.Done():
-			h.wsHandler.BroadcastStatusUpdate(url.ID, "timeout", map[string]string{"error": "Analysis timeout"})
-		default:
-		}
-	}()
+	if _, err := h.crawlerService.AnalyzeURLWithContext(ctx, url.ID); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to queue analysis", slog.Int("url_id", url.ID), slog.String("error", err.Error()))
+	} else {
+		h.publishStatus(url.ID, string(models.StatusQueued))
+	}
 
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Message: "URL added successfully",
@@ -139,7 +155,7 @@ func (h *URLHandler) GetURL(c *gin.Context) {
 	default:
 	}
 
-	url, err := h.crawlerService.GetURL(id)
+	url, err := h.crawlerService.GetURLWithContext(ctx, id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "URL not found"})
 		return
@@ -165,34 +181,18 @@ func (h *URLHandler) AnalyzeURL(c *gin.Context) {
 	default:
 	}
 
-	go func() {
-		bgCtx, bgCancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		defer bgCancel()
-
-		h.wsHandler.BroadcastStatusUpdate(id, "processing", nil)
-
-		err := h.crawlerService.AnalyzeURL(id)
-
-		updatedURL, getErr := h.crawlerService.GetURL(id)
-		if getErr != nil {
-			h.wsHandler.BroadcastStatusUpdate(id, "error", map[string]string{"error": getErr.Error()})
-			return
-		}
-
-		if err != nil {
-			h.wsHandler.BroadcastStatusUpdate(id, "error", updatedURL)
-		} else {
-			h.wsHandler.BroadcastStatusUpdate(id, "completed", updatedURL)
-		}
+	jobID, err := h.crawlerService.AnalyzeURLWithContext(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: err.Error()})
+		return
+	}
 
-		select {
-		case <-bgCtx.Done():
-			h.wsHandler.BroadcastStatusUpdate(id, "timeout", map[string]string{"error": "Analysis timeout"})
-		default:
-		}
-	}()
+	h.publishStatus(id, string(models.StatusQueued))
 
-	c.JSON(http.StatusOK, models.SuccessResponse{Message: "URL analysis started"})
+	c.JSON(http.StatusAccepted, models.SuccessResponse{
+		Message: "URL analysis queued",
+		Data:    map[string]string{"job_id": jobID},
+	})
 }
 
 func (h *URLHandler) DeleteURL(c *gin.Context) {
@@ -212,13 +212,13 @@ func (h *URLHandler) DeleteURL(c *gin.Context) {
 	default:
 	}
 
-	err = h.crawlerService.DeleteURL(id)
+	err = h.crawlerService.DeleteURLWithContext(ctx, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	h.wsHandler.BroadcastStatusUpdate(id, "deleted", nil)
+	h.publishStatus(id, "deleted")
 
 	c.JSON(http.StatusOK, models.SuccessResponse{Message: "URL deleted successfully"})
 }
@@ -240,38 +240,42 @@ func (h *URLHandler) BulkAnalyze(c *gin.Context) {
 	default:
 	}
 
-	for _, id := range req.IDs {
-		go func(urlID int) {
-			bgCtx, bgCancel := context.WithTimeout(context.Background(), 5*time.Minute)
-			defer bgCancel()
-
-			h.wsHandler.BroadcastStatusUpdate(urlID, "processing", nil)
-
-			err := h.crawlerService.AnalyzeURL(urlID)
-
-			updatedURL, getErr := h.crawlerService.GetURL(urlID)
-			if getErr != nil {
-				h.wsHandler.BroadcastStatusUpdate(urlID, "error", map[string]string{"error": getErr.Error()})
-				return
-			}
+	batchID, jobIDs, err := h.crawlerService.AnalyzeURLs(ctx, req.IDs)
+	if len(jobIDs) == 0 {
+		msg := "No URLs could be queued for analysis"
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: msg})
+		return
+	}
 
-			if err != nil {
-				h.wsHandler.BroadcastStatusUpdate(urlID, "error", updatedURL)
-			} else {
-				h.wsHandler.BroadcastStatusUpdate(urlID, "completed", updatedURL)
-			}
+	rejectedIDs := make([]int, 0)
+	for _, id := range req.IDs {
+		if _, ok := jobIDs[id]; !ok {
+			rejectedIDs = append(rejectedIDs, id)
+		}
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "Some URLs could not be queued for analysis",
+			slog.String("batch_id", batchID),
+			slog.Int("rejected", len(rejectedIDs)),
+			slog.String("error", err.Error()))
+	}
 
-			select {
-			case <-bgCtx.Done():
-				h.wsHandler.BroadcastStatusUpdate(urlID, "timeout", map[string]string{"error": "Analysis timeout"})
-			default:
-			}
-		}(id)
+	for id := range jobIDs {
+		h.publishStatus(id, string(models.StatusQueued))
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Bulk analysis started",
-		Data:    map[string]int{"count": len(req.IDs)},
+		Data: map[string]interface{}{
+			"count":        len(req.IDs),
+			"queued":       len(jobIDs),
+			"job_ids":      jobIDs,
+			"rejected_ids": rejectedIDs,
+			"batch_id":     batchID,
+		},
 	})
 }
 
@@ -301,9 +305,9 @@ func (h *URLHandler) BulkDelete(c *gin.Context) {
 		default:
 		}
 
-		if err := h.crawlerService.DeleteURL(id); err == nil {
+		if err := h.crawlerService.DeleteURLWithContext(ctx, id); err == nil {
 			deleted++
-			h.wsHandler.BroadcastStatusUpdate(id, "deleted", nil)
+			h.publishStatus(id, "deleted")
 		}
 	}
 
@@ -337,4 +341,79 @@ func (h *URLHandler) GetBrokenLinks(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, brokenLinks)
-}
\ No newline at end of file
+}
+
+func (h *URLHandler) GetLinks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid URL ID"})
+		return
+	}
+
+	links, err := h.crawlerService.GetLinks(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (h *URLHandler) GetSiteLinkAnalysis(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	host := c.Param("host")
+	if host == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid host"})
+		return
+	}
+
+	analysis, err := h.crawlerService.GetSiteLinkAnalysis(ctx, host)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
+
+func (h *URLHandler) GetDuplicates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	groups, err := h.crawlerService.GetDuplicateGroups(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *URLHandler) CancelAnalysis(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid URL ID"})
+		return
+	}
+
+	jobIDs, err := h.crawlerService.CancelAnalysis(ctx, id)
+	if err != nil {
+		c.JSON(jobErrorStatus(err), models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	h.publishStatus(id, string(models.StatusCancelled))
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "URL analysis cancelled",
+		Data:    map[string][]string{"job_ids": jobIDs},
+	})
+}
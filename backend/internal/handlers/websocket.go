@@ -4,16 +4,26 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"searcher-app/internal/events"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
 type WebSocketHandler struct {
-	clients    map[*websocket.Conn]bool
-	broadcast  chan []byte
-	register   chan *websocket.Conn
-	unregister chan *websocket.Conn
+	clients     map[*websocket.Conn]bool
+	broadcast   chan []byte
+	register    chan *websocket.Conn
+	unregister  chan *websocket.Conn
+	done        chan struct{}
+	stopped     chan struct{}
+	closeOnce   sync.Once
+	clientCount atomic.Int64
+	running     atomic.Bool
 }
 
 type StatusUpdate struct {
@@ -32,15 +42,25 @@ var upgrader = websocket.Upgrader{
 func NewWebSocketHandler() *WebSocketHandler {
 	return &WebSocketHandler{
 		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan []byte),
+		broadcast:  make(chan []byte, 256),
 		register:   make(chan *websocket.Conn),
 		unregister: make(chan *websocket.Conn),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
 	}
 }
 
 func (h *WebSocketHandler) Run() {
+	h.running.Store(true)
+	defer close(h.stopped)
+	defer h.running.Store(false)
+
 	for {
 		select {
+		case <-h.done:
+			h.shutdown()
+			return
+
 		case client := <-h.register:
 			h.clients[client] = true
 			log.Printf("WebSocket client connected. Total: %d", len(h.clients))
@@ -62,7 +82,47 @@ func (h *WebSocketHandler) Run() {
 				}
 			}
 		}
+
+		h.clientCount.Store(int64(len(h.clients)))
+	}
+}
+
+// Alive reports whether the hub is running and accepting clients.
+func (h *WebSocketHandler) Alive() bool {
+	return h.running.Load()
+}
+
+// ClientCount returns the number of connected clients.
+func (h *WebSocketHandler) ClientCount() int {
+	return int(h.clientCount.Load())
+}
+
+// Close delivers queued broadcasts, sends every client a going-away close
+// frame and stops the hub. It blocks until Run has returned.
+func (h *WebSocketHandler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+	<-h.stopped
+}
+
+func (h *WebSocketHandler) shutdown() {
+	for len(h.broadcast) > 0 {
+		message := <-h.broadcast
+		for client := range h.clients {
+			client.WriteMessage(websocket.TextMessage, message)
+		}
 	}
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for client := range h.clients {
+		client.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		client.Close()
+		delete(h.clients, client)
+	}
+	h.clientCount.Store(0)
+
+	log.Printf("WebSocket hub closed")
 }
 
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
@@ -72,11 +132,19 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	h.register <- conn
+	select {
+	case h.register <- conn:
+	case <-h.done:
+		conn.Close()
+		return
+	}
 
 	go func() {
 		defer func() {
-			h.unregister <- conn
+			select {
+			case h.unregister <- conn:
+			case <-h.done:
+			}
 		}()
 
 		for {
@@ -91,23 +159,23 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	}()
 }
 
-func (h *WebSocketHandler) BroadcastStatusUpdate(urlId int, status string, data interface{}) {
+func (h *WebSocketHandler) HandleEvent(event events.Event) {
 	update := StatusUpdate{
-		Type:   "status_update",
-		URLId:  urlId,
-		Status: status,
-		Data:   data,
+		Type:   string(event.Type),
+		URLId:  event.URLID,
+		Status: event.Status,
+		Data:   event.Data,
 	}
 
 	message, err := json.Marshal(update)
 	if err != nil {
-		log.Printf("Error marshaling status update: %v", err)
+		log.Printf("Error marshaling event: %v", err)
 		return
 	}
 
 	select {
 	case h.broadcast <- message:
 	default:
-		log.Printf("Broadcast channel full, dropping message")
+		log.Printf("Broadcast channel full, dropping %s event", event.Type)
 	}
-}
\ No newline at end of file
+}
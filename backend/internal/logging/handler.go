@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"searcher-app/internal/requestid"
+)
+
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h so records logged with a context carry the request ID
+// stored in it.
+func NewHandler(h slog.Handler) slog.Handler {
+	return &contextHandler{Handler: h}
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"searcher-app/internal/database"
+	"searcher-app/internal/worker"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	workersDesc = prometheus.NewDesc(namespace+"_worker_pool_workers",
+		"Number of workers in the pool.", nil, nil)
+	busyWorkersDesc = prometheus.NewDesc(namespace+"_worker_pool_busy_workers",
+		"Number of workers running a job.", nil, nil)
+	queueDepthDesc = prometheus.NewDesc(namespace+"_worker_pool_queue_depth",
+		"Jobs waiting in the pool queue by priority.", []string{"priority"}, nil)
+	queueCapacityDesc = prometheus.NewDesc(namespace+"_worker_pool_queue_capacity",
+		"Total capacity of the pool queues.", nil, nil)
+	runningJobsDesc = prometheus.NewDesc(namespace+"_worker_pool_running_jobs",
+		"Running jobs by job type.", []string{"job_type"}, nil)
+	deferredJobsDesc = prometheus.NewDesc(namespace+"_worker_pool_deferred_jobs",
+		"Jobs waiting for a concurrency slot by job type.", []string{"job_type"}, nil)
+	retriesDesc = prometheus.NewDesc(namespace+"_job_retries_total",
+		"Job retries scheduled by job type.", []string{"job_type"}, nil)
+)
+
+type poolCollector struct {
+	pool *worker.WorkerPool
+}
+
+// NewPoolCollector exposes worker pool statistics, read on every scrape.
+func NewPoolCollector(pool *worker.WorkerPool) prometheus.Collector {
+	return &poolCollector{pool: pool}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workersDesc
+	ch <- busyWorkersDesc
+	ch <- queueDepthDesc
+	ch <- queueCapacityDesc
+	ch <- runningJobsDesc
+	ch <- deferredJobsDesc
+	ch <- retriesDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.GetStats()
+
+	ch <- prometheus.MustNewConstMetric(workersDesc, prometheus.GaugeValue, float64(stats.WorkerCount))
+	ch <- prometheus.MustNewConstMetric(busyWorkersDesc, prometheus.GaugeValue, float64(stats.ActiveJobs))
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.HighPriorityJobs), string(worker.PriorityHigh))
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.LowPriorityJobs), string(worker.PriorityLow))
+	ch <- prometheus.MustNewConstMetric(queueCapacityDesc, prometheus.GaugeValue, float64(stats.QueueCapacity))
+
+	for jobType, concurrency := range stats.Concurrency {
+		ch <- prometheus.MustNewConstMetric(runningJobsDesc, prometheus.GaugeValue, float64(concurrency.Running), string(jobType))
+		ch <- prometheus.MustNewConstMetric(deferredJobsDesc, prometheus.GaugeValue, float64(concurrency.Waiting), string(jobType))
+	}
+	for jobType, retries := range stats.Retries {
+		ch <- prometheus.MustNewConstMetric(retriesDesc, prometheus.CounterValue, float64(retries), string(jobType))
+	}
+}
+
+var (
+	dbMaxOpenDesc = prometheus.NewDesc(namespace+"_db_max_open_connections",
+		"Maximum number of open database connections.", nil, nil)
+	dbOpenDesc = prometheus.NewDesc(namespace+"_db_open_connections",
+		"Open database connections.", nil, nil)
+	dbInUseDesc = prometheus.NewDesc(namespace+"_db_in_use_connections",
+		"Database connections currently in use.", nil, nil)
+	dbIdleDesc = prometheus.NewDesc(namespace+"_db_idle_connections",
+		"Idle database connections.", nil, nil)
+	dbWaitCountDesc = prometheus.NewDesc(namespace+"_db_wait_count_total",
+		"Connections waited for because the pool was exhausted.", nil, nil)
+	dbWaitDurationDesc = prometheus.NewDesc(namespace+"_db_wait_duration_seconds_total",
+		"Time spent waiting for a database connection.", nil, nil)
+	dbClosedDesc = prometheus.NewDesc(namespace+"_db_closed_connections_total",
+		"Database connections closed by the pool by reason.", []string{"reason"}, nil)
+)
+
+type databaseCollector struct {
+	db *database.Database
+}
+
+// NewDatabaseCollector exposes database connection pool statistics.
+func NewDatabaseCollector(db *database.Database) prometheus.Collector {
+	return &databaseCollector{db: db}
+}
+
+func (c *databaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbMaxOpenDesc
+	ch <- dbOpenDesc
+	ch <- dbInUseDesc
+	ch <- dbIdleDesc
+	ch <- dbWaitCountDesc
+	ch <- dbWaitDurationDesc
+	ch <- dbClosedDesc
+}
+
+func (c *databaseCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.GetStats()
+
+	ch <- prometheus.MustNewConstMetric(dbMaxOpenDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbOpenDesc, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbInUseDesc, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(dbIdleDesc, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(dbWaitCountDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(dbClosedDesc, prometheus.CounterValue, float64(stats.MaxIdleClosed), "max_idle")
+	ch <- prometheus.MustNewConstMetric(dbClosedDesc, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), "max_lifetime")
+}
+
+// NewWebSocketClientsGauge reports the number of connected websocket clients.
+func NewWebSocketClientsGauge(count func() int) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Connected websocket clients.",
+	}, func() float64 {
+		return float64(count())
+	})
+}
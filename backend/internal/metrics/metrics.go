@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "searcher"
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	AnalysisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "analysis_duration_seconds",
+		Help:      "Duration of URL analysis jobs by outcome.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"outcome"})
+
+	LinkChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_checks_total",
+		Help:      "Link status checks by outcome.",
+	}, []string{"outcome"})
+)
+
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailed    = "failed"
+	OutcomeCancelled = "cancelled"
+	OutcomeTimeout   = "timeout"
+
+	LinkOK     = "ok"
+	LinkBroken = "broken"
+	LinkError  = "error"
+)
+
+func init() {
+	prometheus.MustRegister(HTTPRequests, HTTPRequestDuration, AnalysisDuration, LinkChecks)
+}
@@ -7,15 +7,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+func envAPIKey() string {
+	if apiKey := os.Getenv("API_KEY"); apiKey != "" {
+		return apiKey
+	}
+	return "dev-api-key-2024"
+}
+
 func APIKeyAuth() gin.HandlerFunc {
+	return NewAPIKeyAuth(envAPIKey())
+}
+
+// NewAPIKeyAuth requires every request to carry expectedAPIKey in the
+// X-API-Key header.
+func NewAPIKeyAuth(expectedAPIKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader("X-API-Key")
 
-		expectedAPIKey := os.Getenv("API_KEY")
-		if expectedAPIKey == "" {
-			expectedAPIKey = "dev-api-key-2024"
-		}
-
 		if apiKey == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "API key is required",
@@ -41,10 +49,7 @@ func APIKeyAuth() gin.HandlerFunc {
 func OptionalAPIKeyAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader("X-API-Key")
-		expectedAPIKey := os.Getenv("API_KEY")
-		if expectedAPIKey == "" {
-			expectedAPIKey = "dev-api-key-2024"
-		}
+		expectedAPIKey := envAPIKey()
 
 		if apiKey == "" {
 			c.Header("X-Auth-Status", "unauthorized")
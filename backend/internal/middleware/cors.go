@@ -41,12 +41,14 @@ func DefaultCORSConfig() CORSConfig {
 			"X-API-Key",
 			"X-Requested-With",
 			"Cache-Control",
+			"X-Request-ID",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
 			"X-RateLimit-Limit",
 			"X-RateLimit-Remaining",
 			"X-RateLimit-Reset",
+			"X-Request-ID",
 		},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"searcher-app/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDKey = "request_id"
+
+// RequestID adopts the caller's X-Request-ID when it is well-formed and
+// generates one otherwise. The ID is echoed in the response and stored in the
+// request context, from where it reaches log lines and queued jobs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -13,6 +14,9 @@ const (
 	StatusProcessing URLStatus = "processing"
 	StatusCompleted  URLStatus = "completed"
 	StatusError      URLStatus = "error"
+	StatusNotHTML    URLStatus = "not_html"
+	StatusCancelled  URLStatus = "cancelled"
+	StatusPurged     URLStatus = "purged"
 )
 
 type URL struct {
@@ -21,6 +25,9 @@ type URL struct {
 	URLHash             string    `json:"-" db:"url_hash"`
 	Title               *string   `json:"title" db:"title"`
 	HTMLVersion         *string   `json:"html_version" db:"html_version"`
+	Charset             *string   `json:"charset" db:"charset"`
+	ContentType         *string   `json:"content_type" db:"content_type"`
+	ContentLength       *int64    `json:"content_length" db:"content_length"`
 	H1Count             int       `json:"h1_count" db:"h1_count"`
 	H2Count             int       `json:"h2_count" db:"h2_count"`
 	H3Count             int       `json:"h3_count" db:"h3_count"`
@@ -31,6 +38,11 @@ type URL struct {
 	ExternalLinksCount  int       `json:"external_links_count" db:"external_links_count"`
 	BrokenLinksCount    int       `json:"broken_links_count" db:"broken_links_count"`
 	HasLoginForm        bool      `json:"has_login_form" db:"has_login_form"`
+	ContentHash         *string   `json:"content_hash" db:"content_hash"`
+	ContentSimhash      *string   `json:"content_simhash" db:"content_simhash"`
+	ContentChanged      *bool     `json:"content_changed" db:"content_changed"`
+	ETag                *string   `json:"etag" db:"etag"`
+	LastModified        *string   `json:"last_modified" db:"last_modified"`
 	Status              URLStatus `json:"status" db:"status"`
 	ErrorMessage        *string   `json:"error_message" db:"error_message"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
@@ -45,6 +57,114 @@ type BrokenLink struct {
 	ErrorMessage *string `json:"error_message" db:"error_message"`
 }
 
+type Link struct {
+	ID         int       `json:"id" db:"id"`
+	URLID      int       `json:"url_id" db:"url_id"`
+	SourceHost string    `json:"source_host" db:"source_host"`
+	TargetURL  string    `json:"target_url" db:"target_url"`
+	AnchorText string    `json:"anchor_text" db:"anchor_text"`
+	Rel        string    `json:"rel" db:"rel"`
+	IsInternal bool      `json:"is_internal" db:"is_internal"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type PageLinkStats struct {
+	URLID                 int    `json:"url_id"`
+	URL                   string `json:"url"`
+	Inlinks               int    `json:"inlinks"`
+	InternalOutlinks      int    `json:"internal_outlinks"`
+	ClickDepth            *int   `json:"click_depth"`
+	IsOrphan              bool   `json:"is_orphan"`
+	HasNoInternalOutlinks bool   `json:"has_no_internal_outlinks"`
+}
+
+type SiteLinkAnalysis struct {
+	Host                string          `json:"host"`
+	RootURLID           *int            `json:"root_url_id"`
+	MaxClickDepth       int             `json:"max_click_depth"`
+	Pages               []PageLinkStats `json:"pages"`
+	OrphanPages         []int           `json:"orphan_pages"`
+	NoInternalOutlinks  []int           `json:"no_internal_outlinks"`
+	UnreachableFromRoot []int           `json:"unreachable_from_root"`
+}
+
+type ContentFingerprint struct {
+	URLID          int     `json:"url_id"`
+	URL            string  `json:"url"`
+	Title          *string `json:"title"`
+	ContentHash    string  `json:"content_hash"`
+	ContentSimhash string  `json:"content_simhash"`
+}
+
+type DuplicateGroup struct {
+	Kind  string               `json:"kind"`
+	Pages []ContentFingerprint `json:"pages"`
+}
+
+type DeadLetterJob struct {
+	ID             int             `json:"id" db:"id"`
+	JobID          string          `json:"job_id" db:"job_id"`
+	JobType        string          `json:"job_type" db:"job_type"`
+	Key            string          `json:"key" db:"job_key"`
+	Priority       string          `json:"priority" db:"priority"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	LastError      string          `json:"last_error" db:"last_error"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	MaxRetry       int             `json:"max_retry" db:"max_retry"`
+	AttemptHistory json.RawMessage `json:"attempt_history" db:"attempt_history"`
+	JobCreatedAt   *time.Time      `json:"job_created_at" db:"job_created_at"`
+	FailedAt       time.Time       `json:"failed_at" db:"failed_at"`
+	ReplayCount    int             `json:"replay_count" db:"replay_count"`
+	LastReplayedAt *time.Time      `json:"last_replayed_at" db:"last_replayed_at"`
+}
+
+type DeadLetterReplayRequest struct {
+	IDs     []int  `json:"ids"`
+	JobType string `json:"job_type"`
+	All     bool   `json:"all"`
+}
+
+type QueuedJob struct {
+	ID             int64           `json:"id" db:"id"`
+	JobID          string          `json:"job_id" db:"job_id"`
+	JobType        string          `json:"job_type" db:"job_type"`
+	Key            string          `json:"key" db:"job_key"`
+	Priority       string          `json:"priority" db:"priority"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Metadata       json.RawMessage `json:"metadata" db:"metadata"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	MaxRetry       int             `json:"max_retry" db:"max_retry"`
+	LastError      string          `json:"last_error" db:"last_error"`
+	LeaseOwner     string          `json:"lease_owner" db:"lease_owner"`
+	LeaseExpiresAt *time.Time      `json:"lease_expires_at" db:"lease_expires_at"`
+	HeartbeatAt    *time.Time      `json:"heartbeat_at" db:"heartbeat_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// JobEvent is an event published in a worker process, stored as JSON for
+// the API process to relay.
+type JobEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	Event     json.RawMessage `json:"event" db:"event"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+type CleanupReport struct {
+	DryRun               bool      `json:"dry_run"`
+	AnalysesPurged       int64     `json:"analyses_purged"`
+	ErrorURLsDeleted     int64     `json:"error_urls_deleted"`
+	BrokenLinksTrimmed   int64     `json:"broken_links_trimmed"`
+	OrphanLinks          int64     `json:"orphan_links"`
+	OrphanBrokenLinks    int64     `json:"orphan_broken_links"`
+	DeadLetterJobsPurged int64     `json:"dead_letter_jobs_purged"`
+	QueueJobsPurged      int64     `json:"queue_jobs_purged"`
+	JobRecordsPruned     int64     `json:"job_records_pruned"`
+	StartedAt            time.Time `json:"started_at"`
+	DurationMS           int64     `json:"duration_ms"`
+}
+
 type URLRequest struct {
 	URL string `json:"url" binding:"required,url"`
 }
@@ -82,12 +202,22 @@ type HeadingCounts struct {
 type URLAnalysisResult struct {
 	Title              string        `json:"title"`
 	HTMLVersion        string        `json:"html_version"`
+	Charset            string        `json:"charset"`
+	ContentType        string        `json:"content_type"`
+	ContentLength      int64         `json:"content_length"`
+	NotHTML            bool          `json:"not_html"`
 	HeadingCounts      HeadingCounts `json:"heading_counts"`
 	InternalLinksCount int           `json:"internal_links_count"`
 	ExternalLinksCount int           `json:"external_links_count"`
 	BrokenLinksCount   int           `json:"broken_links_count"`
 	HasLoginForm       bool          `json:"has_login_form"`
 	BrokenLinks        []BrokenLink  `json:"broken_links"`
+	Links              []Link        `json:"links"`
+	ContentHash        string        `json:"content_hash"`
+	ContentSimhash     string        `json:"content_simhash"`
+	ETag               string        `json:"etag"`
+	LastModified       string        `json:"last_modified"`
+	NotModified        bool          `json:"not_modified"`
 }
 
 func GenerateURLHash(url string) string {
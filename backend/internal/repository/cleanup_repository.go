@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type CleanupRepository interface {
+	PurgeAnalyses(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error)
+	DeleteErrorURLs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error)
+	TrimBrokenLinks(ctx context.Context, maxPerURL int, dryRun bool) (int64, error)
+	PurgeDeadLetterJobs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error)
+	PurgeFinishedQueueJobs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error)
+	VacuumOrphanLinks(ctx context.Context, dryRun bool) (int64, error)
+	VacuumOrphanBrokenLinks(ctx context.Context, dryRun bool) (int64, error)
+}
+
+type MySQLCleanupRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLCleanupRepository(db *sql.DB) CleanupRepository {
+	return &MySQLCleanupRepository{db: db}
+}
+
+// PurgeAnalyses clears the results of analyses not refreshed since olderThan,
+// together with their links and broken links. The URLs themselves are kept as
+// purged until they are analyzed again.
+func (r *MySQLCleanupRepository) PurgeAnalyses(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error) {
+	const stale = `u.status IN ('completed', 'not_html', 'cancelled') AND u.updated_at < ?`
+
+	if dryRun {
+		return r.countOrDelete(ctx, true, "SELECT COUNT(*) FROM urls u WHERE "+stale, "", olderThan)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The URL rows are reset last, since resetting them bumps updated_at.
+	for _, table := range []string{"links", "broken_links"} {
+		query := "DELETE l FROM " + table + " l JOIN urls u ON u.id = l.url_id WHERE " + stale
+		if _, err := tx.ExecContext(ctx, query, olderThan); err != nil {
+			return 0, fmt.Errorf("failed to delete %s of purged analyses: %w", table, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE urls u SET
+			title = NULL, html_version = NULL, charset = NULL, content_type = NULL, content_length = NULL,
+			h1_count = 0, h2_count = 0, h3_count = 0, h4_count = 0, h5_count = 0, h6_count = 0,
+			internal_links_count = 0, external_links_count = 0, broken_links_count = 0, has_login_form = FALSE,
+			content_hash = NULL, content_simhash = NULL, content_changed = NULL, etag = NULL, last_modified = NULL,
+			error_message = NULL, status = 'purged'
+		WHERE `+stale, olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear purged analyses: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (r *MySQLCleanupRepository) DeleteErrorURLs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error) {
+	where := `FROM urls WHERE status = 'error' AND updated_at < ?`
+	return r.countOrDelete(ctx, dryRun, "SELECT COUNT(*) "+where, "DELETE "+where, olderThan)
+}
+
+// TrimBrokenLinks keeps the newest maxPerURL broken links of every URL and
+// lowers the broken link count of the trimmed URLs to match.
+func (r *MySQLCleanupRepository) TrimBrokenLinks(ctx context.Context, maxPerURL int, dryRun bool) (int64, error) {
+	excess := `
+		SELECT id FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY url_id ORDER BY id DESC) AS position
+			FROM broken_links
+		) ranked
+		WHERE position > ?`
+
+	if dryRun {
+		return r.countOrDelete(ctx, true, "SELECT COUNT(*) FROM ("+excess+") excess", "", maxPerURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The counts are capped first, while the URLs over the limit can still be
+	// told apart. updated_at is kept so trimming does not look like a fresh
+	// analysis.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE urls u
+		JOIN (SELECT url_id FROM broken_links GROUP BY url_id HAVING COUNT(*) > ?) trimmed ON trimmed.url_id = u.id
+		SET u.broken_links_count = ?, u.updated_at = u.updated_at`,
+		maxPerURL, maxPerURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update broken link counts: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"DELETE b FROM broken_links b JOIN ("+excess+") excess ON b.id = excess.id", maxPerURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim broken links: %w", err)
+	}
+
+	trimmed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return trimmed, nil
+}
+
+func (r *MySQLCleanupRepository) PurgeDeadLetterJobs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error) {
+	where := `FROM dead_letter_jobs WHERE failed_at < ?`
+	return r.countOrDelete(ctx, dryRun, "SELECT COUNT(*) "+where, "DELETE "+where, olderThan)
+}
+
+// PurgeFinishedQueueJobs deletes job_queue rows that succeeded, failed or were
+// cancelled before olderThan. Pending and leased rows are never touched.
+func (r *MySQLCleanupRepository) PurgeFinishedQueueJobs(ctx context.Context, olderThan time.Time, dryRun bool) (int64, error) {
+	where := `FROM job_queue WHERE status IN ('succeeded', 'failed', 'cancelled') AND updated_at < ?`
+	return r.countOrDelete(ctx, dryRun, "SELECT COUNT(*) "+where, "DELETE "+where, olderThan)
+}
+
+func (r *MySQLCleanupRepository) VacuumOrphanLinks(ctx context.Context, dryRun bool) (int64, error) {
+	where := `FROM links WHERE NOT EXISTS (SELECT 1 FROM urls WHERE urls.id = links.url_id)`
+	return r.countOrDelete(ctx, dryRun, "SELECT COUNT(*) "+where, "DELETE "+where)
+}
+
+func (r *MySQLCleanupRepository) VacuumOrphanBrokenLinks(ctx context.Context, dryRun bool) (int64, error) {
+	where := `FROM broken_links WHERE NOT EXISTS (SELECT 1 FROM urls WHERE urls.id = broken_links.url_id)`
+	return r.countOrDelete(ctx, dryRun, "SELECT COUNT(*) "+where, "DELETE "+where)
+}
+
+func (r *MySQLCleanupRepository) countOrDelete(ctx context.Context, dryRun bool, countQuery, deleteQuery string, args ...interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if dryRun {
+		var count int64
+		if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to count rows for cleanup: %w", err)
+		}
+		return count, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, deleteQuery, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rows during cleanup: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"searcher-app/internal/models"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead-letter job not found")
+
+type DeadLetterRepository interface {
+	Save(ctx context.Context, job *models.DeadLetterJob) error
+	FindByID(ctx context.Context, id int) (*models.DeadLetterJob, error)
+	FindAll(ctx context.Context, filter DeadLetterFilter) ([]models.DeadLetterJob, int, error)
+	MarkReplayed(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int) error
+	Purge(ctx context.Context, filter DeadLetterFilter) (int64, error)
+}
+
+type DeadLetterFilter struct {
+	JobType        string
+	FailedBefore   *time.Time
+	OnlyUnreplayed bool
+	Page           int
+	Limit          int
+}
+
+type MySQLDeadLetterRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLDeadLetterRepository(db *sql.DB) DeadLetterRepository {
+	return &MySQLDeadLetterRepository{db: db}
+}
+
+const deadLetterColumns = `id, job_id, job_type, priority, payload, last_error, attempts, max_retry,
+	attempt_history, job_created_at, failed_at, replay_count, last_replayed_at`
+
+func scanDeadLetter(row rowScanner) (*models.DeadLetterJob, error) {
+	var job models.DeadLetterJob
+	var payload, history []byte
+	var lastError sql.NullString
+	var jobCreatedAt, lastReplayedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID, &job.JobID, &job.JobType, &job.Priority, &payload, &lastError,
+		&job.Attempts, &job.MaxRetry, &history, &jobCreatedAt, &job.FailedAt,
+		&job.ReplayCount, &lastReplayedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = payload
+	job.AttemptHistory = history
+	job.LastError = lastError.String
+	if jobCreatedAt.Valid {
+		job.JobCreatedAt = &jobCreatedAt.Time
+	}
+	if lastReplayedAt.Valid {
+		job.LastReplayedAt = &lastReplayedAt.Time
+	}
+
+	return &job, nil
+}
+
+func (r *MySQLDeadLetterRepository) Save(ctx context.Context, job *models.DeadLetterJob) error {
+	query := `
+		INSERT INTO dead_letter_jobs (job_id, job_type, priority, payload, last_error, attempts,
+			max_retry, attempt_history, job_created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query,
+		job.JobID, job.JobType, job.Priority, nullableJSON(job.Payload), job.LastError,
+		job.Attempts, job.MaxRetry, nullableJSON(job.AttemptHistory), job.JobCreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save dead-letter job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	job.ID = int(id)
+	return nil
+}
+
+func (r *MySQLDeadLetterRepository) FindByID(ctx context.Context, id int) (*models.DeadLetterJob, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letter_jobs WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	job, err := scanDeadLetter(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to find dead-letter job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *MySQLDeadLetterRepository) FindAll(ctx context.Context, filter DeadLetterFilter) ([]models.DeadLetterJob, int, error) {
+	whereClause, args := deadLetterWhere(filter)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM dead_letter_jobs " + whereClause
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead-letter jobs: %w", err)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letter_jobs ` + whereClause +
+		` ORDER BY failed_at DESC, id DESC LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query dead-letter jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.DeadLetterJob
+	for rows.Next() {
+		job, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan dead-letter job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate dead-letter jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+func (r *MySQLDeadLetterRepository) MarkReplayed(ctx context.Context, id int) error {
+	query := `
+		UPDATE dead_letter_jobs
+		SET replay_count = replay_count + 1, last_replayed_at = CURRENT_TIMESTAMP
+		WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark dead-letter job replayed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MySQLDeadLetterRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM dead_letter_jobs WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead-letter job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrDeadLetterNotFound
+	}
+
+	return nil
+}
+
+func (r *MySQLDeadLetterRepository) Purge(ctx context.Context, filter DeadLetterFilter) (int64, error) {
+	whereClause, args := deadLetterWhere(filter)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM dead_letter_jobs "+whereClause, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead-letter jobs: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+func deadLetterWhere(filter DeadLetterFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if filter.JobType != "" {
+		conditions = append(conditions, "job_type = ?")
+		args = append(args, filter.JobType)
+	}
+	if filter.FailedBefore != nil {
+		conditions = append(conditions, "failed_at < ?")
+		args = append(args, *filter.FailedBefore)
+	}
+	if filter.OnlyUnreplayed {
+		conditions = append(conditions, "replay_count = 0")
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
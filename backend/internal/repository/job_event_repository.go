@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"searcher-app/internal/models"
+)
+
+// JobEventRepository stores events published in worker processes so the API
+// process can relay them to its own subscribers.
+type JobEventRepository interface {
+	Append(ctx context.Context, events []json.RawMessage) error
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]models.JobEvent, error)
+	LatestID(ctx context.Context) (int64, error)
+	DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error)
+}
+
+type MySQLJobEventRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLJobEventRepository(db *sql.DB) JobEventRepository {
+	return &MySQLJobEventRepository{db: db}
+}
+
+func (r *MySQLJobEventRepository) Append(ctx context.Context, events []json.RawMessage) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO job_events (event) VALUES " + strings.TrimSuffix(strings.Repeat("(?), ", len(events)), ", ")
+	args := make([]interface{}, len(events))
+	for i, event := range events {
+		args[i] = []byte(event)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to append job events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MySQLJobEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]models.JobEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, event, created_at
+		FROM job_events
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.JobEvent
+	for rows.Next() {
+		var event models.JobEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job event: %w", err)
+		}
+		event.Event = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate job events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *MySQLJobEventRepository) LatestID(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM job_events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get latest job event: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *MySQLJobEventRepository) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM job_events
+		WHERE created_at < DATE_SUB(NOW(3), INTERVAL ? MICROSECOND)`, age.Microseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete job events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"searcher-app/internal/models"
+)
+
+var (
+	ErrLeaseLost          = errors.New("job lease lost")
+	ErrQueuedJobNotFound  = errors.New("queued job not found")
+	ErrQueuedJobFinished  = errors.New("queued job already finished")
+	ErrQueuedJobCancelled = errors.New("queued job cancelled")
+)
+
+type JobQueueRepository interface {
+	Enqueue(ctx context.Context, job *models.QueuedJob) error
+	EnqueueCoalesced(ctx context.Context, job *models.QueuedJob) error
+	Get(ctx context.Context, jobID string) (*models.QueuedJob, error)
+	Cancel(ctx context.Context, jobID string) (*models.QueuedJob, error)
+	CancelByKey(ctx context.Context, key string) ([]models.QueuedJob, error)
+	Lease(ctx context.Context, owner string, jobTypes []string, lease time.Duration) (*models.QueuedJob, error)
+	ExtendLease(ctx context.Context, jobID, owner string, lease time.Duration) error
+	Complete(ctx context.Context, jobID, owner string) error
+	Fail(ctx context.Context, jobID, owner, lastError string) error
+	Release(ctx context.Context, jobID, owner string) error
+	ReleaseExpired(ctx context.Context) (int64, error)
+}
+
+type MySQLJobQueueRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLJobQueueRepository(db *sql.DB) JobQueueRepository {
+	return &MySQLJobQueueRepository{db: db}
+}
+
+const queuedJobColumns = `id, job_id, job_type, job_key, priority, payload, metadata, status, attempts,
+	max_retry, last_error, lease_owner, lease_expires_at, heartbeat_at, created_at, updated_at`
+
+func scanQueuedJob(row rowScanner) (*models.QueuedJob, error) {
+	var job models.QueuedJob
+	var payload, metadata []byte
+	var key, lastError, leaseOwner sql.NullString
+	var leaseExpiresAt, heartbeatAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID, &job.JobID, &job.JobType, &key, &job.Priority, &payload, &metadata, &job.Status,
+		&job.Attempts, &job.MaxRetry, &lastError, &leaseOwner, &leaseExpiresAt, &heartbeatAt,
+		&job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Key = key.String
+	job.Payload = payload
+	job.Metadata = metadata
+	job.LastError = lastError.String
+	job.LeaseOwner = leaseOwner.String
+	if leaseExpiresAt.Valid {
+		job.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+	if heartbeatAt.Valid {
+		job.HeartbeatAt = &heartbeatAt.Time
+	}
+
+	return &job, nil
+}
+
+// Enqueue adds a pending job. Enqueuing a job ID that is already stored, as
+// happens when a recovered job is persisted again on shutdown, resets it to
+// pending unless another process currently holds its lease or it was
+// cancelled.
+func (r *MySQLJobQueueRepository) Enqueue(ctx context.Context, job *models.QueuedJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.insert(ctx, r.db, job)
+}
+
+// EnqueueCoalesced adds a pending job unless a pending job with the same key
+// is already stored, in which case job takes that job's ID and, when job has
+// high priority, the stored job is raised to high priority. Jobs without a
+// key are enqueued as with Enqueue.
+func (r *MySQLJobQueueRepository) EnqueueCoalesced(ctx context.Context, job *models.QueuedJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if job.Key == "" {
+		return r.insert(ctx, r.db, job)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := scanQueuedJob(tx.QueryRowContext(ctx, `
+		SELECT `+queuedJobColumns+`
+		FROM job_queue
+		WHERE job_key = ? AND status = 'pending'
+		ORDER BY id
+		LIMIT 1
+		FOR UPDATE`, job.Key))
+	switch {
+	case err == sql.ErrNoRows:
+		if err := r.insert(ctx, tx, job); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to find pending job: %w", err)
+	default:
+		if job.Priority == "high" && existing.Priority != "high" {
+			if _, err := tx.ExecContext(ctx, `UPDATE job_queue SET priority = 'high' WHERE id = ?`, existing.ID); err != nil {
+				return fmt.Errorf("failed to raise job priority: %w", err)
+			}
+			existing.Priority = "high"
+		}
+		*job = *existing
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit enqueued job: %w", err)
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *MySQLJobQueueRepository) insert(ctx context.Context, db execer, job *models.QueuedJob) error {
+	query := `
+		INSERT INTO job_queue (job_id, job_type, job_key, priority, payload, metadata, max_retry)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			status = IF(status IN ('leased', 'cancelled'), status, 'pending'),
+			available_at = IF(status IN ('leased', 'cancelled'), available_at, NOW(3)),
+			attempts = IF(status IN ('leased', 'cancelled'), attempts, 0),
+			last_error = IF(status IN ('leased', 'cancelled'), last_error, NULL)`
+
+	result, err := db.ExecContext(ctx, query,
+		job.JobID, job.JobType, nullableString(job.Key), job.Priority,
+		nullableJSON(job.Payload), nullableJSON(job.Metadata), job.MaxRetry)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	job.ID = id
+	job.Status = "pending"
+	return nil
+}
+
+// Lease claims the next available job of the given types for owner. Jobs whose
+// lease has expired count as available again, so work held by a worker that
+// died is picked up by the next one, but a job is leased at most max_retry + 1
+// times. Returns nil when nothing is available.
+func (r *MySQLJobQueueRepository) Lease(ctx context.Context, owner string, jobTypes []string, lease time.Duration) (*models.QueuedJob, error) {
+	if len(jobTypes) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(jobTypes)), ", ")
+	query := `
+		SELECT ` + queuedJobColumns + `
+		FROM job_queue
+		WHERE job_type IN (` + placeholders + `)
+		  AND attempts <= max_retry
+		  AND ((status = 'pending' AND available_at <= NOW(3))
+		    OR (status = 'leased' AND lease_expires_at < NOW(3)))
+		ORDER BY priority = 'high' DESC, available_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`
+
+	args := make([]interface{}, len(jobTypes))
+	for i, jobType := range jobTypes {
+		args[i] = jobType
+	}
+
+	job, err := scanQueuedJob(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to select job to lease: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE job_queue
+		SET status = 'leased', lease_owner = ?, attempts = attempts + 1,
+		    lease_expires_at = DATE_ADD(NOW(3), INTERVAL ? MICROSECOND), heartbeat_at = NOW(3)
+		WHERE id = ?`,
+		owner, lease.Microseconds(), job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job lease: %w", err)
+	}
+
+	job.Status = "leased"
+	job.Attempts++
+	job.LeaseOwner = owner
+	return job, nil
+}
+
+func (r *MySQLJobQueueRepository) Get(ctx context.Context, jobID string) (*models.QueuedJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	job, err := scanQueuedJob(r.db.QueryRowContext(ctx,
+		`SELECT `+queuedJobColumns+` FROM job_queue WHERE job_id = ?`, jobID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrQueuedJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get queued job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Cancel marks a pending or leased job as cancelled. A pending job is never
+// leased afterwards; the owner of a leased job learns about it the next time
+// it extends the lease. Finished jobs are returned with ErrQueuedJobFinished.
+func (r *MySQLJobQueueRepository) Cancel(ctx context.Context, jobID string) (*models.QueuedJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	job, err := scanQueuedJob(tx.QueryRowContext(ctx,
+		`SELECT `+queuedJobColumns+` FROM job_queue WHERE job_id = ? FOR UPDATE`, jobID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrQueuedJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get queued job: %w", err)
+	}
+	if job.Status != "pending" && job.Status != "leased" {
+		return job, ErrQueuedJobFinished
+	}
+
+	if _, err := tx.ExecContext(ctx, cancelQueuedJobs+` WHERE id = ?`, job.ID); err != nil {
+		return nil, fmt.Errorf("failed to cancel queued job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job cancellation: %w", err)
+	}
+
+	job.Status = "cancelled"
+	job.LastError = "cancelled"
+	job.LeaseExpiresAt = nil
+	return job, nil
+}
+
+// CancelByKey cancels every pending or leased job with the given key and
+// returns them.
+func (r *MySQLJobQueueRepository) CancelByKey(ctx context.Context, key string) ([]models.QueuedJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+queuedJobColumns+`
+		FROM job_queue
+		WHERE job_key = ? AND status IN ('pending', 'leased')
+		FOR UPDATE`, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find queued jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.QueuedJob
+	for rows.Next() {
+		job, err := scanQueuedJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queued job: %w", err)
+		}
+		job.Status = "cancelled"
+		job.LastError = "cancelled"
+		job.LeaseExpiresAt = nil
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate queued jobs: %w", err)
+	}
+	rows.Close()
+
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		cancelQueuedJobs+` WHERE job_key = ? AND status IN ('pending', 'leased')`, key); err != nil {
+		return nil, fmt.Errorf("failed to cancel queued jobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job cancellation: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// cancelQueuedJobs keeps lease_owner so ExtendLease can tell the owner why
+// its lease is gone.
+const cancelQueuedJobs = `
+	UPDATE job_queue
+	SET status = 'cancelled', last_error = 'cancelled', lease_expires_at = NULL`
+
+// ExtendLease renews owner's lease on a job. It returns ErrQueuedJobCancelled
+// when the job was cancelled while leased and ErrLeaseLost when the lease was
+// lost otherwise.
+func (r *MySQLJobQueueRepository) ExtendLease(ctx context.Context, jobID, owner string, lease time.Duration) error {
+	err := r.updateLeased(ctx, `
+		UPDATE job_queue
+		SET lease_expires_at = DATE_ADD(NOW(3), INTERVAL ? MICROSECOND), heartbeat_at = NOW(3)
+		WHERE job_id = ? AND lease_owner = ? AND status = 'leased'`,
+		lease.Microseconds(), jobID, owner)
+	if err != ErrLeaseLost {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var status string
+	err = r.db.QueryRowContext(ctx,
+		`SELECT status FROM job_queue WHERE job_id = ? AND lease_owner = ?`, jobID, owner).Scan(&status)
+	if err == nil && status == "cancelled" {
+		return ErrQueuedJobCancelled
+	}
+	return ErrLeaseLost
+}
+
+func (r *MySQLJobQueueRepository) Complete(ctx context.Context, jobID, owner string) error {
+	return r.updateLeased(ctx, `
+		UPDATE job_queue
+		SET status = 'succeeded', lease_owner = NULL, lease_expires_at = NULL, last_error = NULL
+		WHERE job_id = ? AND lease_owner = ? AND status = 'leased'`,
+		jobID, owner)
+}
+
+func (r *MySQLJobQueueRepository) Fail(ctx context.Context, jobID, owner, lastError string) error {
+	return r.updateLeased(ctx, `
+		UPDATE job_queue
+		SET status = 'failed', lease_owner = NULL, lease_expires_at = NULL, last_error = ?
+		WHERE job_id = ? AND lease_owner = ? AND status = 'leased'`,
+		lastError, jobID, owner)
+}
+
+func (r *MySQLJobQueueRepository) Release(ctx context.Context, jobID, owner string) error {
+	return r.updateLeased(ctx, `
+		UPDATE job_queue
+		SET status = 'pending', lease_owner = NULL, lease_expires_at = NULL,
+		    attempts = GREATEST(attempts - 1, 0)
+		WHERE job_id = ? AND lease_owner = ? AND status = 'leased'`,
+		jobID, owner)
+}
+
+// ReleaseExpired returns jobs whose lease has expired to the queue. Jobs that
+// have used up their attempts, such as one that keeps killing its worker, are
+// failed instead.
+func (r *MySQLJobQueueRepository) ReleaseExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE job_queue
+		SET status = IF(attempts > max_retry, 'failed', 'pending'),
+		    last_error = IF(attempts > max_retry, 'lease expired on the last attempt', last_error),
+		    lease_owner = NULL, lease_expires_at = NULL
+		WHERE status = 'leased' AND lease_expires_at < NOW(3)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release expired leases: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return released, nil
+}
+
+func (r *MySQLJobQueueRepository) updateLeased(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update leased job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"searcher-app/internal/models"
+)
+
+const linkColumns = `id, url_id, source_host, target_url, anchor_text, rel, is_internal, created_at`
+
+func scanLink(row rowScanner) (*models.Link, error) {
+	var link models.Link
+	var anchorText, rel sql.NullString
+
+	err := row.Scan(
+		&link.ID, &link.URLID, &link.SourceHost, &link.TargetURL,
+		&anchorText, &rel, &link.IsInternal, &link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	link.AnchorText = anchorText.String
+	link.Rel = rel.String
+
+	return &link, nil
+}
+
+func (r *MySQLURLRepository) ReplaceLinks(ctx context.Context, urlID int, links []models.Link) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM links WHERE url_id = ?", urlID); err != nil {
+		return fmt.Errorf("failed to delete links: %w", err)
+	}
+
+	if len(links) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO links (url_id, source_host, target_url, anchor_text, rel, is_internal)
+			VALUES (?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare link insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, link := range links {
+			_, err := stmt.ExecContext(ctx,
+				urlID, link.SourceHost, link.TargetURL, link.AnchorText, link.Rel, link.IsInternal)
+			if err != nil {
+				return fmt.Errorf("failed to save link: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit links: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MySQLURLRepository) FindLinksByURLID(ctx context.Context, urlID int) ([]models.Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM links
+		WHERE url_id = ?
+		ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.queryLinks(ctx, query, urlID)
+}
+
+func (r *MySQLURLRepository) FindInternalLinksByHost(ctx context.Context, host string) ([]models.Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM links
+		WHERE source_host = ? AND is_internal = TRUE
+		ORDER BY url_id, id`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.queryLinks(ctx, query, strings.ToLower(host))
+}
+
+func (r *MySQLURLRepository) queryLinks(ctx context.Context, query string, args ...interface{}) ([]models.Link, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, *link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return links, nil
+}
+
+func (r *MySQLURLRepository) FindURLsByHost(ctx context.Context, host string) ([]models.URL, error) {
+	host = strings.ToLower(host)
+
+	query := `
+		SELECT ` + urlColumns + `
+		FROM urls
+		WHERE url LIKE ? OR url LIKE ?
+		ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, "http://"+host+"%", "https://"+host+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query URLs by host: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []models.URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL: %w", err)
+		}
+
+		// The LIKE prefix also matches hosts such as "example.com.evil.org",
+		// so confirm the parsed host before including the page.
+		parsed, err := url.Parse(u.URL)
+		if err != nil || strings.ToLower(parsed.Host) != host {
+			continue
+		}
+
+		urls = append(urls, *u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return urls, nil
+}
@@ -3,24 +3,41 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"searcher-app/internal/models"
 )
 
+var ErrURLNotFound = errors.New("URL not found")
+
+// ErrAnalysisCancelled is returned by UpdateResult when the URL's analysis was
+// cancelled before its outcome could be stored.
+var ErrAnalysisCancelled = errors.New("URL analysis was cancelled")
+
 type URLRepository interface {
 	Save(ctx context.Context, url *models.URL) error
 	FindByID(ctx context.Context, id int) (*models.URL, error)
 	FindByHash(ctx context.Context, hash string) (*models.URL, error)
 	FindAll(ctx context.Context, filter URLFilter) ([]models.URL, int, error)
 	Update(ctx context.Context, url *models.URL) error
+	UpdateResult(ctx context.Context, url *models.URL) error
+	UpdateStatus(ctx context.Context, id int, status models.URLStatus, errorMessage *string) error
 	Delete(ctx context.Context, id int) error
 	DeleteBatch(ctx context.Context, ids []int) error
 
 	SaveBrokenLink(ctx context.Context, brokenLink *models.BrokenLink) error
 	FindBrokenLinksByURLID(ctx context.Context, urlID int) ([]models.BrokenLink, error)
 	DeleteBrokenLinksByURLID(ctx context.Context, urlID int) error
+
+	ReplaceLinks(ctx context.Context, urlID int, links []models.Link) error
+	FindLinksByURLID(ctx context.Context, urlID int) ([]models.Link, error)
+	FindInternalLinksByHost(ctx context.Context, host string) ([]models.Link, error)
+	FindURLsByHost(ctx context.Context, host string) ([]models.URL, error)
+
+	FindContentFingerprints(ctx context.Context) ([]models.ContentFingerprint, error)
 }
 
 type URLFilter struct {
@@ -42,6 +59,8 @@ type URLFilter struct {
 	MaxBrokenLinks       *int
 	SortBy               string
 	SortDirection        string
+	StuckBefore          *time.Time
+	ExcludeIDs           []int
 }
 
 type MySQLURLRepository struct {
@@ -52,6 +71,70 @@ func NewMySQLURLRepository(db *sql.DB) URLRepository {
 	return &MySQLURLRepository{db: db}
 }
 
+const urlColumns = `id, url, url_hash, title, html_version, charset, content_type, content_length, h1_count, h2_count, h3_count, h4_count, h5_count, h6_count,
+		       internal_links_count, external_links_count, broken_links_count, has_login_form,
+		       content_hash, content_simhash, content_changed, etag, last_modified, status,
+		       error_message, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanURL(row rowScanner) (*models.URL, error) {
+	var url models.URL
+	var title, htmlVersion, charset, contentType, errorMessage sql.NullString
+	var contentLength sql.NullInt64
+	var contentHash, contentSimhash, etag, lastModified sql.NullString
+	var contentChanged sql.NullBool
+
+	err := row.Scan(
+		&url.ID, &url.URL, &url.URLHash, &title, &htmlVersion, &charset, &contentType, &contentLength,
+		&url.H1Count, &url.H2Count, &url.H3Count, &url.H4Count, &url.H5Count, &url.H6Count,
+		&url.InternalLinksCount, &url.ExternalLinksCount, &url.BrokenLinksCount, &url.HasLoginForm,
+		&contentHash, &contentSimhash, &contentChanged, &etag, &lastModified,
+		&url.Status, &errorMessage, &url.CreatedAt, &url.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if title.Valid {
+		url.Title = &title.String
+	}
+	if htmlVersion.Valid {
+		url.HTMLVersion = &htmlVersion.String
+	}
+	if charset.Valid {
+		url.Charset = &charset.String
+	}
+	if contentType.Valid {
+		url.ContentType = &contentType.String
+	}
+	if contentLength.Valid {
+		url.ContentLength = &contentLength.Int64
+	}
+	if errorMessage.Valid {
+		url.ErrorMessage = &errorMessage.String
+	}
+	if contentHash.Valid {
+		url.ContentHash = &contentHash.String
+	}
+	if contentSimhash.Valid {
+		url.ContentSimhash = &contentSimhash.String
+	}
+	if contentChanged.Valid {
+		url.ContentChanged = &contentChanged.Bool
+	}
+	if etag.Valid {
+		url.ETag = &etag.String
+	}
+	if lastModified.Valid {
+		url.LastModified = &lastModified.String
+	}
+
+	return &url, nil
+}
+
 func (r *MySQLURLRepository) Save(ctx context.Context, url *models.URL) error {
 	query := `
 		INSERT INTO urls (url, url_hash, title, html_version, h1_count, h2_count, h3_count, h4_count, h5_count, h6_count,
@@ -82,63 +165,33 @@ func (r *MySQLURLRepository) Save(ctx context.Context, url *models.URL) error {
 
 func (r *MySQLURLRepository) FindByID(ctx context.Context, id int) (*models.URL, error) {
 	query := `
-		SELECT id, url, url_hash, title, html_version, h1_count, h2_count, h3_count, h4_count, h5_count, h6_count,
-		       internal_links_count, external_links_count, broken_links_count, has_login_form, status,
-		       error_message, created_at, updated_at
+		SELECT ` + urlColumns + `
 		FROM urls WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var url models.URL
-	var title, htmlVersion, errorMessage sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&url.ID, &url.URL, &url.URLHash, &title, &htmlVersion,
-		&url.H1Count, &url.H2Count, &url.H3Count, &url.H4Count, &url.H5Count, &url.H6Count,
-		&url.InternalLinksCount, &url.ExternalLinksCount, &url.BrokenLinksCount,
-		&url.HasLoginForm, &url.Status, &errorMessage, &url.CreatedAt, &url.UpdatedAt,
-	)
+	url, err := scanURL(r.db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("URL not found with ID %d", id)
+			return nil, fmt.Errorf("%w with ID %d", ErrURLNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to find URL by ID: %w", err)
 	}
 
-	if title.Valid {
-		url.Title = &title.String
-	}
-	if htmlVersion.Valid {
-		url.HTMLVersion = &htmlVersion.String
-	}
-	if errorMessage.Valid {
-		url.ErrorMessage = &errorMessage.String
-	}
-
-	return &url, nil
+	return url, nil
 }
 
 func (r *MySQLURLRepository) FindByHash(ctx context.Context, hash string) (*models.URL, error) {
 	query := `
-		SELECT id, url, url_hash, title, html_version, h1_count, h2_count, h3_count, h4_count, h5_count, h6_count,
-		       internal_links_count, external_links_count, broken_links_count, has_login_form, status,
-		       error_message, created_at, updated_at
+		SELECT ` + urlColumns + `
 		FROM urls WHERE url_hash = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var url models.URL
-	var title, htmlVersion, errorMessage sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, hash).Scan(
-		&url.ID, &url.URL, &url.URLHash, &title, &htmlVersion,
-		&url.H1Count, &url.H2Count, &url.H3Count, &url.H4Count, &url.H5Count, &url.H6Count,
-		&url.InternalLinksCount, &url.ExternalLinksCount, &url.BrokenLinksCount,
-		&url.HasLoginForm, &url.Status, &errorMessage, &url.CreatedAt, &url.UpdatedAt,
-	)
+	url, err := scanURL(r.db.QueryRowContext(ctx, query, hash))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -147,17 +200,7 @@ func (r *MySQLURLRepository) FindByHash(ctx context.Context, hash string) (*mode
 		return nil, fmt.Errorf("failed to find URL by hash: %w", err)
 	}
 
-	if title.Valid {
-		url.Title = &title.String
-	}
-	if htmlVersion.Valid {
-		url.HTMLVersion = &htmlVersion.String
-	}
-	if errorMessage.Valid {
-		url.ErrorMessage = &errorMessage.String
-	}
-
-	return &url, nil
+	return url, nil
 }
 
 func (r *MySQLURLRepository) FindAll(ctx context.Context, filter URLFilter) ([]models.URL, int, error) {
@@ -229,6 +272,25 @@ func (r *MySQLURLRepository) FindAll(ctx context.Context, filter URLFilter) ([]m
 		args = append(args, *filter.HasLoginForm)
 	}
 
+	if filter.StuckBefore != nil {
+		whereClause += ` AND status = 'processing' AND updated_at < ?
+			AND NOT EXISTS (
+				SELECT 1 FROM job_queue q
+				WHERE q.job_type = 'analyze_url' AND JSON_EXTRACT(q.payload, '$') = urls.id
+				  AND q.status IN ('pending', 'leased')
+			)`
+		args = append(args, *filter.StuckBefore)
+	}
+
+	if len(filter.ExcludeIDs) > 0 {
+		placeholders := make([]string, len(filter.ExcludeIDs))
+		for i, id := range filter.ExcludeIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		whereClause += " AND id NOT IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
 	countQuery := "SELECT COUNT(*) FROM urls " + whereClause
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -266,9 +328,7 @@ func (r *MySQLURLRepository) FindAll(ctx context.Context, filter URLFilter) ([]m
 
 	offset := (filter.Page - 1) * filter.Limit
 	query := `
-		SELECT id, url, url_hash, title, html_version, h1_count, h2_count, h3_count, h4_count, h5_count, h6_count,
-		       internal_links_count, external_links_count, broken_links_count, has_login_form, status,
-		       error_message, created_at, updated_at
+		SELECT ` + urlColumns + `
 		FROM urls ` + whereClause + ` ` + orderClause + `
 		LIMIT ? OFFSET ?`
 
@@ -282,30 +342,12 @@ func (r *MySQLURLRepository) FindAll(ctx context.Context, filter URLFilter) ([]m
 
 	var urls []models.URL
 	for rows.Next() {
-		var url models.URL
-		var title, htmlVersion, errorMessage sql.NullString
-
-		err := rows.Scan(
-			&url.ID, &url.URL, &url.URLHash, &title, &htmlVersion,
-			&url.H1Count, &url.H2Count, &url.H3Count, &url.H4Count, &url.H5Count, &url.H6Count,
-			&url.InternalLinksCount, &url.ExternalLinksCount, &url.BrokenLinksCount,
-			&url.HasLoginForm, &url.Status, &errorMessage, &url.CreatedAt, &url.UpdatedAt,
-		)
+		url, err := scanURL(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan URL: %w", err)
 		}
 
-		if title.Valid {
-			url.Title = &title.String
-		}
-		if htmlVersion.Valid {
-			url.HTMLVersion = &htmlVersion.String
-		}
-		if errorMessage.Valid {
-			url.ErrorMessage = &errorMessage.String
-		}
-
-		urls = append(urls, url)
+		urls = append(urls, *url)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -316,24 +358,78 @@ func (r *MySQLURLRepository) FindAll(ctx context.Context, filter URLFilter) ([]m
 }
 
 func (r *MySQLURLRepository) Update(ctx context.Context, url *models.URL) error {
+	affected, err := r.update(ctx, url, "")
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no URL found with ID %d", url.ID)
+	}
+
+	return nil
+}
+
+// UpdateResult stores the outcome of an analysis like Update, unless the
+// analysis was cancelled meanwhile. The cancelled status is then kept and
+// ErrAnalysisCancelled returned.
+func (r *MySQLURLRepository) UpdateResult(ctx context.Context, url *models.URL) error {
+	affected, err := r.update(ctx, url, "AND status <> 'cancelled'")
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		if _, err := r.FindByID(ctx, url.ID); err != nil {
+			return err
+		}
+		return ErrAnalysisCancelled
+	}
+
+	return nil
+}
+
+func (r *MySQLURLRepository) update(ctx context.Context, url *models.URL, condition string) (int64, error) {
 	query := `
 		UPDATE urls SET 
-			title = ?, html_version = ?, h1_count = ?, h2_count = ?, h3_count = ?, h4_count = ?, h5_count = ?, h6_count = ?,
+			title = ?, html_version = ?, charset = ?, content_type = ?, content_length = ?,
+			h1_count = ?, h2_count = ?, h3_count = ?, h4_count = ?, h5_count = ?, h6_count = ?,
 			internal_links_count = ?, external_links_count = ?, broken_links_count = ?, has_login_form = ?,
+			content_hash = ?, content_simhash = ?, content_changed = ?, etag = ?, last_modified = ?,
 			status = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`
+		WHERE id = ? ` + condition
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	result, err := r.db.ExecContext(ctx, query,
-		url.Title, url.HTMLVersion,
+		url.Title, url.HTMLVersion, url.Charset, url.ContentType, url.ContentLength,
 		url.H1Count, url.H2Count, url.H3Count, url.H4Count, url.H5Count, url.H6Count,
 		url.InternalLinksCount, url.ExternalLinksCount, url.BrokenLinksCount,
-		url.HasLoginForm, url.Status, url.ErrorMessage, url.ID)
+		url.HasLoginForm, url.ContentHash, url.ContentSimhash, url.ContentChanged, url.ETag, url.LastModified,
+		url.Status, url.ErrorMessage, url.ID)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to update URL: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return affected, nil
+}
 
+func (r *MySQLURLRepository) UpdateStatus(ctx context.Context, id int, status models.URLStatus, errorMessage *string) error {
+	query := "UPDATE urls SET status = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, status, errorMessage, id)
 	if err != nil {
-		return fmt.Errorf("failed to update URL: %w", err)
+		return fmt.Errorf("failed to update URL status: %w", err)
 	}
 
 	affected, err := result.RowsAffected()
@@ -342,7 +438,7 @@ func (r *MySQLURLRepository) Update(ctx context.Context, url *models.URL) error
 	}
 
 	if affected == 0 {
-		return fmt.Errorf("no URL found with ID %d", url.ID)
+		return fmt.Errorf("no URL found with ID %d", id)
 	}
 
 	return nil
@@ -489,4 +585,44 @@ func (r *MySQLURLRepository) DeleteBrokenLinksByURLID(ctx context.Context, urlID
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *MySQLURLRepository) FindContentFingerprints(ctx context.Context) ([]models.ContentFingerprint, error) {
+	query := `
+		SELECT id, url, title, content_hash, content_simhash
+		FROM urls
+		WHERE content_hash IS NOT NULL AND content_simhash IS NOT NULL
+		  AND content_hash <> SHA2('', 256)
+		ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query content fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	var fingerprints []models.ContentFingerprint
+	for rows.Next() {
+		var fp models.ContentFingerprint
+		var title sql.NullString
+
+		if err := rows.Scan(&fp.URLID, &fp.URL, &title, &fp.ContentHash, &fp.ContentSimhash); err != nil {
+			return nil, fmt.Errorf("failed to scan content fingerprint: %w", err)
+		}
+
+		if title.Valid {
+			fp.Title = &title.String
+		}
+
+		fingerprints = append(fingerprints, fp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return fingerprints, nil
+}
@@ -0,0 +1,47 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const Header = "X-Request-ID"
+
+// Incoming IDs longer than this are replaced rather than logged.
+const maxLength = 128
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a random 128-bit ID in hex.
+func New() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether an ID supplied by a client is safe to adopt: not
+// empty, not overly long and limited to printable ASCII without spaces.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
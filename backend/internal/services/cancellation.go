@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/worker"
+)
+
+// CancelJob cancels a job in this process's pool or, failing that, one it
+// forwarded to the durable queue.
+func (s *enhancedCrawlerService) CancelJob(ctx context.Context, jobID string) error {
+	rec, err := s.workerPool.CancelJob(jobID)
+	if errors.Is(err, worker.ErrJobNotFound) {
+		if rec, err = s.workerPool.CancelForwardedJob(ctx, jobID); err == nil {
+			s.markForwardedCancelled(ctx, rec)
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cancel job %s: %w", jobID, err)
+	}
+
+	s.markAnalysisCancelled(ctx, rec)
+	return nil
+}
+
+func (s *enhancedCrawlerService) CancelAnalysis(ctx context.Context, urlID int) ([]string, error) {
+	if urlID <= 0 {
+		return nil, fmt.Errorf("invalid URL ID: %d", urlID)
+	}
+
+	var cancelled []string
+	for _, rec := range s.workerPool.ListJobs(worker.JobFilter{Type: worker.JobTypeAnalyzeURL}) {
+		if rec.State.IsFinal() {
+			continue
+		}
+		if id, ok := rec.Payload.(int); !ok || id != urlID {
+			continue
+		}
+
+		cancelledRec, err := s.workerPool.CancelJob(rec.ID)
+		if err != nil {
+			continue
+		}
+
+		s.markAnalysisCancelled(ctx, cancelledRec)
+		cancelled = append(cancelled, rec.ID)
+	}
+
+	forwarded, err := s.workerPool.CancelForwardedKey(ctx, analyzeJobKey(urlID))
+	for _, rec := range forwarded {
+		s.markForwardedCancelled(ctx, rec)
+		cancelled = append(cancelled, rec.ID)
+	}
+	if err != nil {
+		if len(cancelled) == 0 {
+			return nil, fmt.Errorf("failed to cancel analysis of URL %d: %w", urlID, err)
+		}
+		s.logger.ErrorContext(ctx, "Failed to cancel forwarded analysis jobs",
+			slog.Int("url_id", urlID),
+			slog.String("error", err.Error()))
+	}
+
+	if len(cancelled) == 0 {
+		return nil, fmt.Errorf("no active analysis for URL %d: %w", urlID, worker.ErrJobNotFound)
+	}
+
+	return cancelled, nil
+}
+
+func (s *enhancedCrawlerService) markAnalysisCancelled(ctx context.Context, rec worker.JobRecord) {
+	if rec.Type != worker.JobTypeAnalyzeURL {
+		return
+	}
+
+	urlID, ok := rec.Payload.(int)
+	if !ok {
+		return
+	}
+
+	msg := "Analysis cancelled"
+	if err := s.urlRepo.UpdateStatus(ctx, urlID, models.StatusCancelled, &msg); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to mark URL as cancelled",
+			slog.Int("url_id", urlID),
+			slog.String("job_id", rec.ID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// markForwardedCancelled also notifies subscribers, since no worker reports
+// the result of a forwarded job cancelled before it was leased.
+func (s *enhancedCrawlerService) markForwardedCancelled(ctx context.Context, rec worker.JobRecord) {
+	s.markAnalysisCancelled(ctx, rec)
+
+	if urlID, ok := rec.Payload.(int); ok && rec.Type == worker.JobTypeAnalyzeURL {
+		s.publishStatus(urlID, models.StatusCancelled, worker.Job{ID: rec.ID, Type: rec.Type})
+	}
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"bytes"
+	"io"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func decodeBody(body []byte, contentType string) (io.Reader, string) {
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+
+	if name == "utf-8" {
+		body = bytes.TrimPrefix(body, utf8BOM)
+	}
+
+	return transform.NewReader(bytes.NewReader(body), enc.NewDecoder()), name
+}
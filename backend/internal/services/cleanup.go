@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/worker"
+)
+
+type CleanupService interface {
+	Start(ctx context.Context)
+	ScheduleCleanup(dryRun bool) (string, error)
+	RunCleanup(ctx context.Context, dryRun bool) (*models.CleanupReport, error)
+}
+
+type CleanupConfig struct {
+	Interval              time.Duration `envconfig:"CLEANUP_INTERVAL" default:"24h"`
+	AnalysisRetentionDays int           `envconfig:"CLEANUP_ANALYSIS_RETENTION_DAYS" default:"0"`
+	ErrorRetentionDays    int           `envconfig:"CLEANUP_ERROR_RETENTION_DAYS" default:"30"`
+	MaxBrokenLinksPerURL  int           `envconfig:"CLEANUP_MAX_BROKEN_LINKS_PER_URL" default:"500"`
+	JobRetentionDays      int           `envconfig:"CLEANUP_JOB_RETENTION_DAYS" default:"7"`
+	VacuumOrphans         bool          `envconfig:"CLEANUP_VACUUM_ORPHANS" default:"true"`
+	DryRun                bool          `envconfig:"CLEANUP_DRY_RUN" default:"false"`
+}
+
+type cleanupService struct {
+	repo       repository.CleanupRepository
+	workerPool *worker.WorkerPool
+	config     *CleanupConfig
+	logger     *slog.Logger
+}
+
+func NewCleanupService(repo repository.CleanupRepository, workerPool *worker.WorkerPool, config *CleanupConfig, logger *slog.Logger) CleanupService {
+	service := &cleanupService{
+		repo:       repo,
+		workerPool: workerPool,
+		config:     config,
+		logger:     logger,
+	}
+
+	workerPool.RegisterHandler(worker.JobTypeCleanup, service.handleCleanupJob)
+	workerPool.SetTimeoutPolicy(worker.JobTypeCleanup, worker.TimeoutPolicy{Timeout: 10 * time.Minute})
+
+	return service
+}
+
+func (s *cleanupService) Start(ctx context.Context) {
+	if s.config.Interval <= 0 {
+		s.logger.Info("Periodic cleanup disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ScheduleCleanup(s.config.DryRun); err != nil {
+					s.logger.Error("Failed to schedule cleanup", slog.String("error", err.Error()))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Periodic cleanup scheduled",
+		slog.Duration("interval", s.config.Interval),
+		slog.Bool("dry_run", s.config.DryRun))
+}
+
+func (s *cleanupService) ScheduleCleanup(dryRun bool) (string, error) {
+	job := worker.Job{
+		ID:        fmt.Sprintf("cleanup_%d", time.Now().UnixNano()),
+		Type:      worker.JobTypeCleanup,
+		Priority:  worker.PriorityLow,
+		Payload:   dryRun,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.workerPool.AddJob(job); err != nil {
+		return "", fmt.Errorf("failed to queue cleanup job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+func (s *cleanupService) handleCleanupJob(ctx context.Context, job worker.Job) (interface{}, error) {
+	dryRun, ok := job.Payload.(bool)
+	if !ok {
+		return nil, worker.Permanent(fmt.Errorf("invalid job payload: expected bool, got %T", job.Payload))
+	}
+
+	return s.RunCleanup(ctx, dryRun)
+}
+
+func (s *cleanupService) RunCleanup(ctx context.Context, dryRun bool) (*models.CleanupReport, error) {
+	report := &models.CleanupReport{
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+	}
+
+	steps := []struct {
+		name    string
+		enabled bool
+		target  *int64
+		run     func() (int64, error)
+	}{
+		{
+			name:    "purge analyses",
+			enabled: s.config.AnalysisRetentionDays > 0,
+			target:  &report.AnalysesPurged,
+			run: func() (int64, error) {
+				return s.repo.PurgeAnalyses(ctx, daysAgo(s.config.AnalysisRetentionDays), dryRun)
+			},
+		},
+		{
+			name:    "delete error URLs",
+			enabled: s.config.ErrorRetentionDays > 0,
+			target:  &report.ErrorURLsDeleted,
+			run: func() (int64, error) {
+				return s.repo.DeleteErrorURLs(ctx, daysAgo(s.config.ErrorRetentionDays), dryRun)
+			},
+		},
+		{
+			name:    "trim broken links",
+			enabled: s.config.MaxBrokenLinksPerURL > 0,
+			target:  &report.BrokenLinksTrimmed,
+			run: func() (int64, error) {
+				return s.repo.TrimBrokenLinks(ctx, s.config.MaxBrokenLinksPerURL, dryRun)
+			},
+		},
+		{
+			name:    "purge dead-letter jobs",
+			enabled: s.config.JobRetentionDays > 0,
+			target:  &report.DeadLetterJobsPurged,
+			run: func() (int64, error) {
+				return s.repo.PurgeDeadLetterJobs(ctx, daysAgo(s.config.JobRetentionDays), dryRun)
+			},
+		},
+		{
+			name:    "purge finished queue jobs",
+			enabled: s.config.JobRetentionDays > 0,
+			target:  &report.QueueJobsPurged,
+			run: func() (int64, error) {
+				return s.repo.PurgeFinishedQueueJobs(ctx, daysAgo(s.config.JobRetentionDays), dryRun)
+			},
+		},
+		{
+			name:    "prune job records",
+			enabled: s.config.JobRetentionDays > 0,
+			target:  &report.JobRecordsPruned,
+			run: func() (int64, error) {
+				return int64(s.workerPool.PruneJobs(daysAgo(s.config.JobRetentionDays), dryRun)), nil
+			},
+		},
+		{
+			name:    "vacuum orphaned links",
+			enabled: s.config.VacuumOrphans,
+			target:  &report.OrphanLinks,
+			run: func() (int64, error) {
+				return s.repo.VacuumOrphanLinks(ctx, dryRun)
+			},
+		},
+		{
+			name:    "vacuum orphaned broken links",
+			enabled: s.config.VacuumOrphans,
+			target:  &report.OrphanBrokenLinks,
+			run: func() (int64, error) {
+				return s.repo.VacuumOrphanBrokenLinks(ctx, dryRun)
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		count, err := step.run()
+		if err != nil {
+			return report, fmt.Errorf("cleanup step %q failed: %w", step.name, err)
+		}
+		*step.target = count
+		worker.Heartbeat(ctx)
+	}
+
+	report.DurationMS = time.Since(report.StartedAt).Milliseconds()
+
+	s.logger.Info("Cleanup finished",
+		slog.Bool("dry_run", dryRun),
+		slog.Int64("analyses_purged", report.AnalysesPurged),
+		slog.Int64("error_urls_deleted", report.ErrorURLsDeleted),
+		slog.Int64("broken_links_trimmed", report.BrokenLinksTrimmed),
+		slog.Int64("orphan_links", report.OrphanLinks),
+		slog.Int64("orphan_broken_links", report.OrphanBrokenLinks),
+		slog.Int64("dead_letter_jobs_purged", report.DeadLetterJobsPurged),
+		slog.Int64("queue_jobs_purged", report.QueueJobsPurged),
+		slog.Int64("job_records_pruned", report.JobRecordsPruned),
+		slog.Int64("duration_ms", report.DurationMS))
+
+	return report, nil
+}
+
+func daysAgo(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+type contentKind int
+
+const (
+	contentKindHTML contentKind = iota
+	contentKindXHTML
+	contentKindNonHTML
+)
+
+func classifyContent(contentType string, body []byte) (contentKind, string) {
+	mediaType := parseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = parseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case mediaType == "text/html":
+		if hasXMLPrologue(body) {
+			return contentKindXHTML, mediaType
+		}
+		return contentKindHTML, mediaType
+	case mediaType == "application/xhtml+xml":
+		return contentKindXHTML, mediaType
+	case mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"):
+		if xmlRootElement(body) == "html" {
+			return contentKindXHTML, mediaType
+		}
+		return contentKindNonHTML, mediaType
+	default:
+		return contentKindNonHTML, mediaType
+	}
+}
+
+func parseMediaType(contentType string) string {
+	if strings.TrimSpace(contentType) == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mediaType
+}
+
+func hasXMLPrologue(body []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, utf8BOM), " \t\r\n")
+	return bytes.HasPrefix(trimmed, []byte("<?xml"))
+}
+
+func xmlRootElement(body []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return strings.ToLower(start.Name.Local)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import "testing"
+
+func TestClassifyContent(t *testing.T) {
+	const (
+		htmlPage    = "<!DOCTYPE html><html><head><title>Page</title></head><body></body></html>"
+		xhtmlPage   = `<?xml version="1.0" encoding="UTF-8"?><html xmlns="http://www.w3.org/1999/xhtml"><head><title>Page</title></head></html>`
+		feed        = `<?xml version="1.0"?><rss version="2.0"><channel></channel></rss>`
+		svgImage    = `<svg xmlns="http://www.w3.org/2000/svg"></svg>`
+		pngPrefix   = "\x89PNG\r\n\x1a\n"
+		bomXHTML    = "\xef\xbb\xbf" + xhtmlPage
+		spacedXHTML = "\n  " + xhtmlPage
+	)
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		wantKind      contentKind
+		wantMediaType string
+	}{
+		{"html", "text/html", htmlPage, contentKindHTML, "text/html"},
+		{"html with charset", "text/html; charset=utf-8", htmlPage, contentKindHTML, "text/html"},
+		{"upper case media type", "Text/HTML", htmlPage, contentKindHTML, "text/html"},
+		{"malformed parameters", "text/html; charset", htmlPage, contentKindHTML, "text/html"},
+		{"xhtml served as html", "text/html", xhtmlPage, contentKindXHTML, "text/html"},
+		{"xhtml after a byte order mark", "text/html", bomXHTML, contentKindXHTML, "text/html"},
+		{"xhtml after whitespace", "text/html", spacedXHTML, contentKindXHTML, "text/html"},
+		{"xhtml media type", "application/xhtml+xml", xhtmlPage, contentKindXHTML, "application/xhtml+xml"},
+		{"xml with an html root", "application/xml", xhtmlPage, contentKindXHTML, "application/xml"},
+		{"text xml with an html root", "text/xml", xhtmlPage, contentKindXHTML, "text/xml"},
+		{"xml feed", "application/rss+xml", feed, contentKindNonHTML, "application/rss+xml"},
+		{"svg", "image/svg+xml", svgImage, contentKindNonHTML, "image/svg+xml"},
+		{"sniffed html", "", htmlPage, contentKindHTML, "text/html"},
+		{"sniffed from octet stream", "application/octet-stream", htmlPage, contentKindHTML, "text/html"},
+		{"sniffed xml with an html root", "", xhtmlPage, contentKindXHTML, "text/xml"},
+		{"png", "image/png", pngPrefix, contentKindNonHTML, "image/png"},
+		{"sniffed png", "", pngPrefix, contentKindNonHTML, "image/png"},
+		{"plain text", "text/plain", "hello", contentKindNonHTML, "text/plain"},
+		{"json", "application/json", `{"html": true}`, contentKindNonHTML, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, mediaType := classifyContent(tt.contentType, []byte(tt.body))
+			if kind != tt.wantKind || mediaType != tt.wantMediaType {
+				t.Errorf("classifyContent(%q) = (%v, %q), want (%v, %q)",
+					tt.contentType, kind, mediaType, tt.wantKind, tt.wantMediaType)
+			}
+		})
+	}
+}
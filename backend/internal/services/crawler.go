@@ -2,18 +2,25 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"searcher-app/internal/events"
+	"searcher-app/internal/metrics"
 	"searcher-app/internal/models"
 	"searcher-app/internal/repository"
 	"searcher-app/internal/worker"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/html"
 )
 
@@ -21,16 +28,22 @@ type CrawlerService interface {
 	AddURL(urlStr string) (*models.URL, error)
 	GetURLs(page, limit int, search string) ([]models.URL, int, error)
 	GetURL(id int) (*models.URL, error)
-	AnalyzeURL(id int) error
+	AnalyzeURL(id int) (string, error)
 	DeleteURL(id int) error
 	AddURLWithContext(ctx context.Context, urlStr string) (*models.URL, error)
 	GetURLsWithContext(ctx context.Context, filter repository.URLFilter) ([]models.URL, int, error)
 	GetURLWithContext(ctx context.Context, id int) (*models.URL, error)
-	AnalyzeURLWithContext(ctx context.Context, id int) error
+	AnalyzeURLWithContext(ctx context.Context, id int) (string, error)
 	DeleteURLWithContext(ctx context.Context, id int) error
-	AnalyzeURLs(ctx context.Context, ids []int) error
+	AnalyzeURLs(ctx context.Context, ids []int) (string, map[int]string, error)
 	DeleteURLs(ctx context.Context, ids []int) error
 	GetBrokenLinks(ctx context.Context, urlID int) ([]models.BrokenLink, error)
+	GetLinks(ctx context.Context, urlID int) ([]models.Link, error)
+	GetSiteLinkAnalysis(ctx context.Context, host string) (*models.SiteLinkAnalysis, error)
+	GetDuplicateGroups(ctx context.Context) ([]models.DuplicateGroup, error)
+	CancelJob(ctx context.Context, jobID string) error
+	CancelAnalysis(ctx context.Context, urlID int) ([]string, error)
+	FindStuckURLs(ctx context.Context, filter repository.URLFilter, stuckAfter time.Duration) ([]models.URL, int, error)
 }
 
 type CrawlerConfig struct {
@@ -41,29 +54,44 @@ type CrawlerConfig struct {
 	MaxResponseSize     int64         `envconfig:"CRAWLER_MAX_RESPONSE_SIZE" default:"10485760"`
 	RetryAttempts       int           `envconfig:"CRAWLER_RETRY_ATTEMPTS" default:"3"`
 	RetryDelay          time.Duration `envconfig:"CRAWLER_RETRY_DELAY" default:"1s"`
+
+	ConditionalRequests       bool `envconfig:"CRAWLER_CONDITIONAL_REQUESTS" default:"true"`
+	RecheckLinksOnNotModified bool `envconfig:"CRAWLER_RECHECK_LINKS_ON_NOT_MODIFIED" default:"false"`
+
+	AnalyzeTimeout   time.Duration `envconfig:"CRAWLER_ANALYZE_TIMEOUT" default:"5m"`
+	CrawlTimeout     time.Duration `envconfig:"CRAWLER_CRAWL_TIMEOUT" default:"1h"`
+	HeartbeatTimeout time.Duration `envconfig:"CRAWLER_HEARTBEAT_TIMEOUT" default:"1m"`
+	StuckAfter       time.Duration `envconfig:"CRAWLER_STUCK_AFTER" default:"15m"`
 }
 
 type enhancedCrawlerService struct {
 	urlRepo    repository.URLRepository
 	workerPool *worker.WorkerPool
+	batches    *worker.BatchWorkerPool
+	events     *events.Bus
 	httpClient *http.Client
 	logger     *slog.Logger
 	config     *CrawlerConfig
+
+	retryPolicy worker.RetryPolicy
+	// fetchSlots caps the page fetches running at once across analysis and
+	// crawl jobs. It is nil when CRAWLER_MAX_CONCURRENT is 0.
+	fetchSlots chan struct{}
 }
 
-func NewCrawlerService(db repository.URLRepository, workerPool *worker.WorkerPool, config *CrawlerConfig, logger *slog.Logger) CrawlerService {
+func NewCrawlerService(db repository.URLRepository, workerPool *worker.WorkerPool, batches *worker.BatchWorkerPool, bus *events.Bus, config *CrawlerConfig, logger *slog.Logger) CrawlerService {
 	httpClient := &http.Client{
 		Timeout: config.RequestTimeout,
-		Transport: &http.Transport{
+		Transport: otelhttp.NewTransport(&http.Transport{
 			MaxIdleConns:        100,
 			MaxIdleConnsPerHost: 10,
 			IdleConnTimeout:     90 * time.Second,
 			DisableKeepAlives:   false,
 			DisableCompression:  false,
-		},
+		}),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= config.MaxRedirects {
-				return fmt.Errorf("too many redirects")
+				return errTooManyRedirects
 			}
 			return nil
 		},
@@ -72,13 +100,31 @@ func NewCrawlerService(db repository.URLRepository, workerPool *worker.WorkerPoo
 	service := &enhancedCrawlerService{
 		urlRepo:    db,
 		workerPool: workerPool,
+		batches:    batches,
+		events:     bus,
 		httpClient: httpClient,
 		logger:     logger,
 		config:     config,
+
+		retryPolicy: crawlRetryPolicy(config),
+	}
+	if config.MaxConcurrentCrawls > 0 {
+		service.fetchSlots = make(chan struct{}, config.MaxConcurrentCrawls)
 	}
 
 	workerPool.RegisterHandler(worker.JobTypeAnalyzeURL, service.handleAnalyzeJob)
+	workerPool.SetRetryPolicy(worker.JobTypeAnalyzeURL, service.retryPolicy)
+	workerPool.SetTimeoutPolicy(worker.JobTypeAnalyzeURL, worker.TimeoutPolicy{
+		Timeout:          config.AnalyzeTimeout,
+		HeartbeatTimeout: config.HeartbeatTimeout,
+	})
+	workerPool.SetTimeoutPolicy(worker.JobTypeCrawlURL, worker.TimeoutPolicy{
+		Timeout:          config.CrawlTimeout,
+		HeartbeatTimeout: config.HeartbeatTimeout,
+	})
 	workerPool.RegisterHandler(worker.JobTypeCrawlURL, service.handleCrawlJob)
+	workerPool.AddResultListener(service.publishJobResult)
+	workerPool.AddProgressListener(service.publishJobProgress)
 
 	return service
 }
@@ -109,10 +155,10 @@ func (s *enhancedCrawlerService) GetURL(id int) (*models.URL, error) {
 	return s.getURL(ctx, id)
 }
 
-func (s *enhancedCrawlerService) AnalyzeURL(id int) error {
+func (s *enhancedCrawlerService) AnalyzeURL(id int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return s.analyzeURL(ctx, id)
+	return s.analyzeURL(ctx, id, worker.PriorityHigh)
 }
 
 func (s *enhancedCrawlerService) DeleteURL(id int) error {
@@ -134,42 +180,80 @@ func (s *enhancedCrawlerService) GetURLWithContext(ctx context.Context, id int)
 	return s.getURL(ctx, id)
 }
 
-func (s *enhancedCrawlerService) AnalyzeURLWithContext(ctx context.Context, id int) error {
-	return s.analyzeURL(ctx, id)
+func (s *enhancedCrawlerService) AnalyzeURLWithContext(ctx context.Context, id int) (string, error) {
+	return s.analyzeURL(ctx, id, worker.PriorityHigh)
 }
 
 func (s *enhancedCrawlerService) DeleteURLWithContext(ctx context.Context, id int) error {
 	return s.deleteURL(ctx, id)
 }
 
-func (s *enhancedCrawlerService) AnalyzeURLs(ctx context.Context, ids []int) error {
+func (s *enhancedCrawlerService) AnalyzeURLs(ctx context.Context, ids []int) (batchID string, jobIDs map[int]string, err error) {
+	jobIDs = make(map[int]string, len(ids))
 	if len(ids) == 0 {
-		return nil
+		return "", jobIDs, nil
 	}
 
+	batch := worker.BatchJob{
+		ID:   fmt.Sprintf("bulk_analyze_%d", time.Now().UnixNano()),
+		Jobs: make([]worker.Job, 0, len(ids)),
+	}
+
+	ctx, span := tracer.Start(ctx, "enqueue batch",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("batch.id", batch.ID),
+			attribute.Int("batch.size", len(ids)),
+		))
+	defer func() { endSpan(span, err) }()
+
 	for _, id := range ids {
-		if err := s.analyzeURL(ctx, id); err != nil {
-			s.logger.Error("Failed to queue analysis job", slog.Int("url_id", id), slog.String("error", err.Error()))
+		job := s.newAnalyzeJob(id, worker.PriorityLow)
+		attachJobContext(ctx, &job)
+		batch.Jobs = append(batch.Jobs, job)
+	}
+	batch.Callback = func(results []worker.JobResult) {
+		s.publishBatchFinished(batch.ID, results)
+	}
+
+	queued, rejected := s.batches.SubmitBatch(batch)
+
+	var errs []error
+	for i, job := range batch.Jobs {
+		urlID := job.Payload.(int)
+		if err, ok := rejected[job.ID]; ok {
+			s.logger.ErrorContext(ctx, "Failed to queue analysis job", slog.Int("url_id", urlID), slog.String("error", err.Error()))
+			errs = append(errs, fmt.Errorf("URL %d: %w", urlID, err))
+			continue
 		}
+		jobIDs[urlID] = queued[i]
 	}
 
-	return nil
+	return batch.ID, jobIDs, errors.Join(errs...)
 }
 
-func (s *enhancedCrawlerService) DeleteURLs(ctx context.Context, ids []int) error {
+func (s *enhancedCrawlerService) DeleteURLs(ctx context.Context, ids []int) (err error) {
 	if len(ids) == 0 {
 		return nil
 	}
 
+	ctx, span := tracer.Start(ctx, "CrawlerService.DeleteURLs",
+		trace.WithAttributes(attribute.Int("url.count", len(ids))))
+	defer func() { endSpan(span, err) }()
+
 	if err := s.urlRepo.DeleteBatch(ctx, ids); err != nil {
 		return fmt.Errorf("failed to delete URLs: %w", err)
 	}
 
-	s.logger.Info("URLs deleted successfully", slog.Int("count", len(ids)))
+	s.logger.InfoContext(ctx, "URLs deleted successfully", slog.Int("count", len(ids)))
 	return nil
 }
 
-func (s *enhancedCrawlerService) GetBrokenLinks(ctx context.Context, urlID int) ([]models.BrokenLink, error) {
+func (s *enhancedCrawlerService) GetBrokenLinks(ctx context.Context, urlID int) (_ []models.BrokenLink, err error) {
+	ctx, span := tracer.Start(ctx, "CrawlerService.GetBrokenLinks",
+		trace.WithAttributes(attribute.Int("url.id", urlID)))
+	defer func() { endSpan(span, err) }()
+
 	if urlID <= 0 {
 		return nil, fmt.Errorf("invalid URL ID: %d", urlID)
 	}
@@ -183,7 +267,11 @@ func (s *enhancedCrawlerService) GetBrokenLinks(ctx context.Context, urlID int)
 }
 
 
-func (s *enhancedCrawlerService) addURL(ctx context.Context, urlStr string) (*models.URL, error) {
+func (s *enhancedCrawlerService) addURL(ctx context.Context, urlStr string) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "CrawlerService.AddURL",
+		trace.WithAttributes(attribute.String("url.full", urlStr)))
+	defer func() { endSpan(span, err) }()
+
 	if err := s.validateURL(urlStr); err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
@@ -191,7 +279,7 @@ func (s *enhancedCrawlerService) addURL(ctx context.Context, urlStr string) (*mo
 	urlHash := models.GenerateURLHash(urlStr)
 
 	if existingURL, err := s.urlRepo.FindByHash(ctx, urlHash); err == nil && existingURL != nil {
-		s.logger.Info("URL already exists", slog.String("url", urlStr), slog.Int("id", existingURL.ID))
+		s.logger.InfoContext(ctx, "URL already exists", slog.String("url", urlStr), slog.Int("id", existingURL.ID))
 		return existingURL, nil
 	}
 
@@ -205,11 +293,14 @@ func (s *enhancedCrawlerService) addURL(ctx context.Context, urlStr string) (*mo
 		return nil, fmt.Errorf("failed to save URL: %w", err)
 	}
 
-	s.logger.Info("URL added successfully", slog.String("url", urlStr), slog.Int("id", newURL.ID))
+	s.logger.InfoContext(ctx, "URL added successfully", slog.String("url", urlStr), slog.Int("id", newURL.ID))
 	return newURL, nil
 }
 
-func (s *enhancedCrawlerService) getURLs(ctx context.Context, filter repository.URLFilter) ([]models.URL, int, error) {
+func (s *enhancedCrawlerService) getURLs(ctx context.Context, filter repository.URLFilter) (_ []models.URL, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "CrawlerService.GetURLs")
+	defer func() { endSpan(span, err) }()
+
 	if filter.Page < 1 {
 		filter.Page = 1
 	}
@@ -225,7 +316,11 @@ func (s *enhancedCrawlerService) getURLs(ctx context.Context, filter repository.
 	return urls, total, nil
 }
 
-func (s *enhancedCrawlerService) getURL(ctx context.Context, id int) (*models.URL, error) {
+func (s *enhancedCrawlerService) getURL(ctx context.Context, id int) (_ *models.URL, err error) {
+	ctx, span := tracer.Start(ctx, "CrawlerService.GetURL",
+		trace.WithAttributes(attribute.Int("url.id", id)))
+	defer func() { endSpan(span, err) }()
+
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid URL ID: %d", id)
 	}
@@ -238,23 +333,47 @@ func (s *enhancedCrawlerService) getURL(ctx context.Context, id int) (*models.UR
 	return url, nil
 }
 
-func (s *enhancedCrawlerService) analyzeURL(ctx context.Context, id int) error {
-	job := worker.Job{
-		ID:        fmt.Sprintf("analyze_%d_%d", id, time.Now().Unix()),
+func (s *enhancedCrawlerService) analyzeURL(ctx context.Context, id int, priority worker.JobPriority) (_ string, err error) {
+	job := s.newAnalyzeJob(id, priority)
+
+	_, span := startEnqueueSpan(ctx, &job)
+	span.SetAttributes(attribute.Int("url.id", id))
+	defer func() { endSpan(span, err) }()
+
+	jobID, err := s.workerPool.AddCoalescedJob(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue analysis job: %w", err)
+	}
+	if jobID != job.ID {
+		span.SetAttributes(attribute.String("job.coalesced_into", jobID))
+	}
+
+	return jobID, nil
+}
+
+func (s *enhancedCrawlerService) newAnalyzeJob(id int, priority worker.JobPriority) worker.Job {
+	return worker.Job{
+		ID:        fmt.Sprintf("analyze_%d_%d", id, time.Now().UnixNano()),
 		Type:      worker.JobTypeAnalyzeURL,
+		Key:       analyzeJobKey(id),
+		Priority:  priority,
 		Payload:   id,
 		MaxRetry:  s.config.RetryAttempts,
 		CreatedAt: time.Now(),
 	}
+}
 
-	if err := s.workerPool.AddJob(job); err != nil {
-		return fmt.Errorf("failed to queue analysis job: %w", err)
-	}
-
-	return nil
+// analyzeJobKey identifies the analysis jobs of a URL for coalescing and
+// cancellation.
+func analyzeJobKey(urlID int) string {
+	return fmt.Sprintf("%s:%d", worker.JobTypeAnalyzeURL, urlID)
 }
 
-func (s *enhancedCrawlerService) deleteURL(ctx context.Context, id int) error {
+func (s *enhancedCrawlerService) deleteURL(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "CrawlerService.DeleteURL",
+		trace.WithAttributes(attribute.Int("url.id", id)))
+	defer func() { endSpan(span, err) }()
+
 	if id <= 0 {
 		return fmt.Errorf("invalid URL ID: %d", id)
 	}
@@ -263,40 +382,104 @@ func (s *enhancedCrawlerService) deleteURL(ctx context.Context, id int) error {
 		return fmt.Errorf("failed to delete URL: %w", err)
 	}
 
-	s.logger.Info("URL deleted successfully", slog.Int("id", id))
+	s.logger.InfoContext(ctx, "URL deleted successfully", slog.Int("id", id))
 	return nil
 }
 
 
-func (s *enhancedCrawlerService) handleAnalyzeJob(ctx context.Context, job worker.Job) (interface{}, error) {
+func analysisOutcome(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case worker.TimedOut(ctx):
+		return metrics.OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return metrics.OutcomeCancelled
+	default:
+		return metrics.OutcomeFailed
+	}
+}
+
+// finalAttempt reports whether the pool gives up on job after it fails with
+// err. Until then the URL stays processing, so a failure that the next attempt
+// recovers from is never shown as an error.
+func (s *enhancedCrawlerService) finalAttempt(job worker.Job, err error) bool {
+	return job.Retry >= job.MaxRetry || !s.retryPolicy.ShouldRetry(err)
+}
+
+func (s *enhancedCrawlerService) handleAnalyzeJob(ctx context.Context, job worker.Job) (data interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.AnalysisDuration.WithLabelValues(analysisOutcome(ctx, err)).Observe(time.Since(start).Seconds())
+	}()
+	ctx = jobContext(ctx, job)
+
 	urlID, ok := job.Payload.(int)
 	if !ok {
-		return nil, fmt.Errorf("invalid job payload: expected int, got %T", job.Payload)
+		return nil, worker.Permanent(fmt.Errorf("invalid job payload: expected int, got %T", job.Payload))
 	}
 
-	s.logger.Info("Starting URL analysis", slog.Int("url_id", urlID))
+	s.logger.InfoContext(ctx, "Starting URL analysis", slog.Int("url_id", urlID))
 
 	url, err := s.urlRepo.FindByID(ctx, urlID)
 	if err != nil {
+		if errors.Is(err, repository.ErrURLNotFound) {
+			return nil, worker.Permanent(fmt.Errorf("failed to find URL: %w", err))
+		}
 		return nil, fmt.Errorf("failed to find URL: %w", err)
 	}
 
+	// Decided before the status update below, which overwrites the state
+	// the stored validators belong to.
+	conditional := s.config.ConditionalRequests && url.Status == models.StatusCompleted && url.ContentHash != nil
+
 	url.Status = models.StatusProcessing
 	if err := s.urlRepo.Update(ctx, url); err != nil {
 		return nil, fmt.Errorf("failed to update URL status: %w", err)
 	}
+	s.publishStatus(urlID, models.StatusProcessing, job)
 
-	result, err := s.crawlURL(ctx, url.URL)
+	reportPhase(ctx, phaseFetching)
+
+	result, err := s.crawlURL(ctx, url, conditional)
 	if err != nil {
+		if worker.TimedOut(ctx) {
+			cause := context.Cause(ctx)
+			if !s.finalAttempt(job, cause) {
+				return nil, fmt.Errorf("analysis timed out: %w", cause)
+			}
+			url.Status = models.StatusError
+			errMsg := "Analysis timed out: " + cause.Error()
+			url.ErrorMessage = &errMsg
+			s.urlRepo.UpdateResult(context.WithoutCancel(ctx), url)
+			return nil, fmt.Errorf("analysis timed out: %w", cause)
+		}
+		if ctxErr := ctx.Err(); ctxErr == context.Canceled {
+			return nil, fmt.Errorf("analysis cancelled: %w", ctxErr)
+		}
+		if !s.finalAttempt(job, err) {
+			return nil, fmt.Errorf("failed to crawl URL: %w", err)
+		}
 		url.Status = models.StatusError
 		errMsg := err.Error()
 		url.ErrorMessage = &errMsg
-		s.urlRepo.Update(ctx, url)
+		s.urlRepo.UpdateResult(ctx, url)
 		return nil, fmt.Errorf("failed to crawl URL: %w", err)
 	}
 
+	if result.NotModified {
+		return s.completeNotModified(ctx, url, result)
+	}
+
+	if result.NotHTML {
+		return s.completeNotHTML(ctx, url, result)
+	}
+
 	url.Title = &result.Title
 	url.HTMLVersion = &result.HTMLVersion
+	url.Charset = optionalString(result.Charset)
+	url.ContentType = optionalString(result.ContentType)
+	url.ContentLength = &result.ContentLength
 	url.H1Count = result.HeadingCounts.H1
 	url.H2Count = result.HeadingCounts.H2
 	url.H3Count = result.HeadingCounts.H3
@@ -307,28 +490,133 @@ func (s *enhancedCrawlerService) handleAnalyzeJob(ctx context.Context, job worke
 	url.ExternalLinksCount = result.ExternalLinksCount
 	url.BrokenLinksCount = result.BrokenLinksCount
 	url.HasLoginForm = result.HasLoginForm
+	if url.ContentHash != nil {
+		changed := *url.ContentHash != result.ContentHash
+		url.ContentChanged = &changed
+	}
+	url.ContentHash = &result.ContentHash
+	url.ContentSimhash = optionalString(result.ContentSimhash)
+	url.ETag = optionalString(result.ETag)
+	url.LastModified = optionalString(result.LastModified)
 	url.Status = models.StatusCompleted
 	url.ErrorMessage = nil
 
-	if err := s.urlRepo.Update(ctx, url); err != nil {
-		return nil, fmt.Errorf("failed to update URL with results: %w", err)
+	reportPhase(ctx, phaseSaving)
+
+	if err := s.saveResult(ctx, url); err != nil {
+		return nil, err
 	}
 
-	if len(result.BrokenLinks) > 0 {
-		if err := s.urlRepo.DeleteBrokenLinksByURLID(ctx, urlID); err != nil {
-			s.logger.Error("Failed to clear existing broken links", slog.Int("url_id", urlID), slog.String("error", err.Error()))
-		}
+	if err := s.urlRepo.ReplaceLinks(ctx, urlID, result.Links); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save link graph", slog.Int("url_id", urlID), slog.String("error", err.Error()))
+	}
 
-		for _, brokenLink := range result.BrokenLinks {
-			brokenLink.URLID = urlID
-			if err := s.urlRepo.SaveBrokenLink(ctx, &brokenLink); err != nil {
-				s.logger.Error("Failed to save broken link", slog.Int("url_id", urlID), slog.String("link", brokenLink.LinkURL), slog.String("error", err.Error()))
-			}
+	s.replaceBrokenLinks(ctx, urlID, result.BrokenLinks)
+
+	s.logger.InfoContext(ctx, "URL analysis completed", slog.Int("url_id", urlID))
+	return url, nil
+}
+
+// saveResult stores the outcome of an analysis. One cancelled while it was
+// finishing keeps its cancelled status, and the job ends as cancelled.
+func (s *enhancedCrawlerService) saveResult(ctx context.Context, target *models.URL) error {
+	err := s.urlRepo.UpdateResult(ctx, target)
+	if errors.Is(err, repository.ErrAnalysisCancelled) {
+		return fmt.Errorf("%w (%w)", err, context.Canceled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update URL with results: %w", err)
+	}
+	return nil
+}
+
+func (s *enhancedCrawlerService) completeNotModified(ctx context.Context, target *models.URL, result *models.URLAnalysisResult) (interface{}, error) {
+	s.logger.InfoContext(ctx, "URL not modified since last analysis", slog.Int("url_id", target.ID))
+
+	unchanged := false
+	target.ContentChanged = &unchanged
+	if result.ETag != "" {
+		target.ETag = &result.ETag
+	}
+	if result.LastModified != "" {
+		target.LastModified = &result.LastModified
+	}
+
+	var recheck *models.URLAnalysisResult
+	if s.config.RecheckLinksOnNotModified {
+		links, err := s.urlRepo.FindLinksByURLID(ctx, target.ID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to load stored links for recheck", slog.Int("url_id", target.ID), slog.String("error", err.Error()))
+		} else if baseURL, err := url.Parse(target.URL); err == nil {
+			recheck = &models.URLAnalysisResult{BrokenLinks: []models.BrokenLink{}}
+			s.analyzeLinks(ctx, links, recheck, baseURL)
+			target.BrokenLinksCount = recheck.BrokenLinksCount
 		}
 	}
 
-	s.logger.Info("URL analysis completed", slog.Int("url_id", urlID))
-	return url, nil
+	target.Status = models.StatusCompleted
+	target.ErrorMessage = nil
+
+	if err := s.saveResult(ctx, target); err != nil {
+		return nil, err
+	}
+
+	if recheck != nil {
+		s.replaceBrokenLinks(ctx, target.ID, recheck.BrokenLinks)
+	}
+
+	s.logger.InfoContext(ctx, "URL analysis completed", slog.Int("url_id", target.ID), slog.Bool("not_modified", true))
+	return target, nil
+}
+
+func (s *enhancedCrawlerService) completeNotHTML(ctx context.Context, target *models.URL, result *models.URLAnalysisResult) (interface{}, error) {
+	s.logger.InfoContext(ctx, "URL is not an HTML document",
+		slog.Int("url_id", target.ID),
+		slog.String("content_type", result.ContentType),
+		slog.Int64("content_length", result.ContentLength))
+
+	target.Title = nil
+	target.HTMLVersion = nil
+	target.Charset = nil
+	target.ContentType = optionalString(result.ContentType)
+	target.ContentLength = &result.ContentLength
+	target.H1Count, target.H2Count, target.H3Count = 0, 0, 0
+	target.H4Count, target.H5Count, target.H6Count = 0, 0, 0
+	target.InternalLinksCount = 0
+	target.ExternalLinksCount = 0
+	target.BrokenLinksCount = 0
+	target.HasLoginForm = false
+	target.ContentHash = nil
+	target.ContentSimhash = nil
+	target.ContentChanged = nil
+	target.ETag = optionalString(result.ETag)
+	target.LastModified = optionalString(result.LastModified)
+	target.Status = models.StatusNotHTML
+	target.ErrorMessage = nil
+
+	if err := s.saveResult(ctx, target); err != nil {
+		return nil, err
+	}
+
+	if err := s.urlRepo.ReplaceLinks(ctx, target.ID, nil); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to clear link graph", slog.Int("url_id", target.ID), slog.String("error", err.Error()))
+	}
+	s.replaceBrokenLinks(ctx, target.ID, nil)
+
+	return target, nil
+}
+
+func (s *enhancedCrawlerService) replaceBrokenLinks(ctx context.Context, urlID int, brokenLinks []models.BrokenLink) {
+	if err := s.urlRepo.DeleteBrokenLinksByURLID(ctx, urlID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to clear existing broken links", slog.Int("url_id", urlID), slog.String("error", err.Error()))
+	}
+
+	for _, brokenLink := range brokenLinks {
+		brokenLink.URLID = urlID
+		if err := s.urlRepo.SaveBrokenLink(ctx, &brokenLink); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to save broken link", slog.Int("url_id", urlID), slog.String("link", brokenLink.LinkURL), slog.String("error", err.Error()))
+		}
+	}
 }
 
 func (s *enhancedCrawlerService) handleCrawlJob(ctx context.Context, job worker.Job) (interface{}, error) {
@@ -336,48 +624,137 @@ func (s *enhancedCrawlerService) handleCrawlJob(ctx context.Context, job worker.
 }
 
 
-func (s *enhancedCrawlerService) crawlURL(ctx context.Context, urlStr string) (*models.URLAnalysisResult, error) {
+func (s *enhancedCrawlerService) crawlURL(ctx context.Context, target *models.URL, conditional bool) (_ *models.URLAnalysisResult, err error) {
+	urlStr := target.URL
+
+	ctx, span := tracer.Start(ctx, "crawl",
+		trace.WithAttributes(
+			attribute.Int("url.id", target.ID),
+			attribute.String("url.full", urlStr),
+		))
+	defer func() { endSpan(span, err) }()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, worker.Permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	req.Header.Set("User-Agent", s.config.UserAgent)
 
+	if conditional {
+		if target.ETag != nil && *target.ETag != "" {
+			req.Header.Set("If-None-Match", *target.ETag)
+		}
+		if target.LastModified != nil && *target.LastModified != "" {
+			req.Header.Set("If-Modified-Since", *target.LastModified)
+		}
+	}
+
+	release, err := s.acquireFetchSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		return &models.URLAnalysisResult{
+			NotModified:  true,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		return nil, newHTTPStatusError(resp)
 	}
 
 	limitedReader := &io.LimitedReader{R: resp.Body, N: s.config.MaxResponseSize}
 
-	doc, err := html.Parse(limitedReader)
+	body, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	release()
+	reportPhase(ctx, phaseParsing)
+
+	contentLength := int64(len(body))
+	if resp.ContentLength > contentLength {
+		contentLength = resp.ContentLength
+	}
+
+	kind, mediaType := classifyContent(resp.Header.Get("Content-Type"), body)
+	if kind == contentKindNonHTML {
+		return &models.URLAnalysisResult{
+			NotHTML:       true,
+			ContentType:   mediaType,
+			ContentLength: contentLength,
+			ETag:          resp.Header.Get("ETag"),
+			LastModified:  resp.Header.Get("Last-Modified"),
+		}, nil
+	}
+
+	decoded, charsetName := decodeBody(body, resp.Header.Get("Content-Type"))
+
+	// Browsers parse XHTML as XML unless it is served as text/html. A document
+	// that is not well-formed is still analysed with the HTML parser.
+	var doc *html.Node
+	if kind == contentKindXHTML && mediaType != "text/html" {
+		if doc, err = parseXHTML(body); err != nil {
+			s.logger.WarnContext(ctx, "Document is not well-formed XHTML, parsing it as HTML",
+				slog.Int("url_id", target.ID), slog.String("error", err.Error()))
+		}
+	}
+	if doc == nil {
+		doc, err = html.Parse(decoded)
+		if err != nil {
+			return nil, worker.Permanent(fmt.Errorf("failed to parse HTML: %w", err))
+		}
 	}
 
 	result := &models.URLAnalysisResult{
-		HTMLVersion:   s.detectHTMLVersion(doc),
+		HTMLVersion:   s.detectHTMLVersion(doc, hasXMLPrologue(body)),
+		Charset:       charsetName,
+		ContentType:   mediaType,
+		ContentLength: contentLength,
 		HeadingCounts: models.HeadingCounts{},
 		BrokenLinks:   []models.BrokenLink{},
+		Links:         []models.Link{},
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+	}
+
+	if kind == contentKindXHTML && result.HTMLVersion == "HTML5" {
+		result.HTMLVersion = "XHTML5"
 	}
 
 	baseURL, err := url.Parse(urlStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+		return nil, worker.Permanent(fmt.Errorf("failed to parse base URL: %w", err))
 	}
 
 	links := s.collectLinks(doc, baseURL)
 
 	s.analyzeHTMLNode(doc, result, baseURL)
 
+	result.ContentHash, result.ContentSimhash = contentFingerprint(doc)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("crawl.links", len(links)))
 	s.analyzeLinks(ctx, links, result, baseURL)
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -402,13 +779,33 @@ func (s *enhancedCrawlerService) validateURL(urlStr string) error {
 	return nil
 }
 
-func (s *enhancedCrawlerService) detectHTMLVersion(doc *html.Node) string {
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// detectHTMLVersion reads the version from the doctype. Without one, a
+// document that opens with an XML declaration is XHTML.
+func (s *enhancedCrawlerService) detectHTMLVersion(doc *html.Node, xmlDeclared bool) string {
 	var findDoctype func(*html.Node) string
 	findDoctype = func(n *html.Node) string {
 		if n.Type == html.DoctypeNode {
+			// The parser keeps the doctype's name in Data and the public and
+			// system identifiers, which name the version, in attributes.
 			doctype := strings.ToLower(n.Data)
+			public := ""
+			for _, attr := range n.Attr {
+				if attr.Key == "public" {
+					public = attr.Val
+				}
+				if attr.Val != "" {
+					doctype += " " + strings.ToLower(attr.Val)
+				}
+			}
 			
-			if doctype == "html" {
+			if strings.EqualFold(n.Data, "html") && public == "" {
 				return "HTML5"
 			}
 			
@@ -460,17 +857,13 @@ func (s *enhancedCrawlerService) detectHTMLVersion(doc *html.Node) string {
 		return version
 	}
 	
-	if s.hasXMLDeclaration(doc) {
+	if xmlDeclared {
 		return "XHTML"
 	}
 	
 	return "HTML5"
 }
 
-func (s *enhancedCrawlerService) hasXMLDeclaration(doc *html.Node) bool {
-	return false
-}
-
 func (s *enhancedCrawlerService) analyzeHTMLNode(n *html.Node, result *models.URLAnalysisResult, baseURL *url.URL) {
 	if n.Type == html.ElementNode {
 		switch strings.ToLower(n.Data) {
@@ -521,23 +914,31 @@ func (s *enhancedCrawlerService) extractTextContent(n *html.Node) string {
 	return strings.TrimSpace(text.String())
 }
 
-func (s *enhancedCrawlerService) collectLinks(doc *html.Node, baseURL *url.URL) []string {
-	var links []string
+func (s *enhancedCrawlerService) collectLinks(doc *html.Node, baseURL *url.URL) []models.Link {
+	var links []models.Link
 	var collect func(*html.Node)
 	collect = func(n *html.Node) {
 		if n.Type == html.ElementNode && strings.ToLower(n.Data) == "a" {
+			href := ""
+			rel := ""
 			for _, attr := range n.Attr {
-				if attr.Key == "href" && attr.Val != "" {
-					href := strings.TrimSpace(attr.Val)
-					if href != "" && !strings.HasPrefix(href, "#") && 
-					   !strings.HasPrefix(href, "javascript:") && 
-					   !strings.HasPrefix(href, "mailto:") &&
-					   !strings.HasPrefix(href, "tel:") {
-						links = append(links, href)
-					}
-					break
+				switch attr.Key {
+				case "href":
+					href = strings.TrimSpace(attr.Val)
+				case "rel":
+					rel = strings.TrimSpace(attr.Val)
 				}
 			}
+			if href != "" && !strings.HasPrefix(href, "#") &&
+				!strings.HasPrefix(href, "javascript:") &&
+				!strings.HasPrefix(href, "mailto:") &&
+				!strings.HasPrefix(href, "tel:") {
+				links = append(links, models.Link{
+					TargetURL:  href,
+					AnchorText: truncateRunes(strings.Join(strings.Fields(s.extractTextContent(n)), " "), maxAnchorTextLength),
+					Rel:        truncateRunes(rel, maxRelLength),
+				})
+			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			collect(c)
@@ -547,30 +948,53 @@ func (s *enhancedCrawlerService) collectLinks(doc *html.Node, baseURL *url.URL)
 	return links
 }
 
-func (s *enhancedCrawlerService) analyzeLinks(ctx context.Context, links []string, result *models.URLAnalysisResult, baseURL *url.URL) {
+func (s *enhancedCrawlerService) analyzeLinks(ctx context.Context, links []models.Link, result *models.URLAnalysisResult, baseURL *url.URL) {
 	uniqueLinks := make(map[string]bool)
+	sourceHost := strings.ToLower(baseURL.Host)
 	
-	for _, link := range links {
-		parsedLink, err := url.Parse(link)
+	for i, link := range links {
+		if ctx.Err() != nil {
+			return
+		}
+		reportLinkProgress(ctx, i, len(links))
+
+		parsedLink, err := url.Parse(link.TargetURL)
 		if err != nil {
 			continue
 		}
 		
 		resolvedURL := baseURL.ResolveReference(parsedLink)
 		resolvedStr := resolvedURL.String()
+		isInternal := resolvedURL.Host == baseURL.Host
+
+		if len(resolvedStr) <= maxLinkURLLength {
+			link.SourceHost = sourceHost
+			link.TargetURL = resolvedStr
+			link.IsInternal = isInternal
+			result.Links = append(result.Links, link)
+		}
 		
 		if uniqueLinks[resolvedStr] {
 			continue
 		}
 		uniqueLinks[resolvedStr] = true
 		
-		if resolvedURL.Host == baseURL.Host {
+		if isInternal {
 			result.InternalLinksCount++
 		} else {
 			result.ExternalLinksCount++
 		}
 		
-		if statusCode, err := s.checkLinkStatus(ctx, resolvedStr); err != nil || statusCode >= 400 {
+		statusCode, err := s.checkLinkStatus(ctx, resolvedStr)
+		switch {
+		case err != nil:
+			metrics.LinkChecks.WithLabelValues(metrics.LinkError).Inc()
+		case statusCode >= 400:
+			metrics.LinkChecks.WithLabelValues(metrics.LinkBroken).Inc()
+		default:
+			metrics.LinkChecks.WithLabelValues(metrics.LinkOK).Inc()
+		}
+		if err != nil || statusCode >= 400 {
 			result.BrokenLinksCount++
 			brokenLink := models.BrokenLink{
 				LinkURL:    resolvedStr,
@@ -583,9 +1007,35 @@ func (s *enhancedCrawlerService) analyzeLinks(ctx context.Context, links []strin
 			result.BrokenLinks = append(result.BrokenLinks, brokenLink)
 		}
 	}
+
+	reportLinkProgress(ctx, len(links), len(links))
 }
 
-func (s *enhancedCrawlerService) checkLinkStatus(ctx context.Context, linkURL string) (int, error) {
+// acquireFetchSlot waits until fewer than CRAWLER_MAX_CONCURRENT page fetches
+// are running. The returned release may be called more than once.
+func (s *enhancedCrawlerService) acquireFetchSlot(ctx context.Context) (func(), error) {
+	if s.fetchSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.fetchSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to fetch URL: %w", ctx.Err())
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-s.fetchSlots }) }, nil
+}
+
+func (s *enhancedCrawlerService) checkLinkStatus(ctx context.Context, linkURL string) (statusCode int, err error) {
+	ctx, span := tracer.Start(ctx, "check link",
+		trace.WithAttributes(attribute.String("url.full", linkURL)))
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		endSpan(span, err)
+	}()
+
 	linkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/worker"
+)
+
+type DeadLetterService interface {
+	ListDeadLetters(ctx context.Context, filter repository.DeadLetterFilter) ([]models.DeadLetterJob, int, error)
+	GetDeadLetter(ctx context.Context, id int) (*models.DeadLetterJob, error)
+	ReplayDeadLetter(ctx context.Context, id int) (string, error)
+	ReplayDeadLetters(ctx context.Context, req models.DeadLetterReplayRequest) (map[int]string, error)
+	DeleteDeadLetter(ctx context.Context, id int) error
+	PurgeDeadLetters(ctx context.Context, filter repository.DeadLetterFilter) (int64, error)
+}
+
+const maxDeadLetterReplayBatch = 1000
+
+type deadLetterService struct {
+	repo       repository.DeadLetterRepository
+	workerPool *worker.WorkerPool
+	logger     *slog.Logger
+}
+
+func NewDeadLetterService(repo repository.DeadLetterRepository, workerPool *worker.WorkerPool, logger *slog.Logger) DeadLetterService {
+	service := &deadLetterService{
+		repo:       repo,
+		workerPool: workerPool,
+		logger:     logger,
+	}
+
+	workerPool.SetDeadLetterHandler(service.handleDeadLetter)
+
+	return service
+}
+
+func (s *deadLetterService) handleDeadLetter(ctx context.Context, job worker.Job, jobErr error) error {
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	history, err := json.Marshal(job.History)
+	if err != nil {
+		return fmt.Errorf("failed to encode attempt history: %w", err)
+	}
+
+	entry := &models.DeadLetterJob{
+		JobID:          job.ID,
+		JobType:        string(job.Type),
+		Priority:       string(job.Priority),
+		Payload:        payload,
+		Attempts:       len(job.History),
+		MaxRetry:       job.MaxRetry,
+		AttemptHistory: history,
+	}
+	if jobErr != nil {
+		entry.LastError = jobErr.Error()
+	}
+	if !job.CreatedAt.IsZero() {
+		createdAt := job.CreatedAt
+		entry.JobCreatedAt = &createdAt
+	}
+
+	return s.repo.Save(ctx, entry)
+}
+
+func (s *deadLetterService) ListDeadLetters(ctx context.Context, filter repository.DeadLetterFilter) ([]models.DeadLetterJob, int, error) {
+	return s.repo.FindAll(ctx, filter)
+}
+
+func (s *deadLetterService) GetDeadLetter(ctx context.Context, id int) (*models.DeadLetterJob, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *deadLetterService) ReplayDeadLetter(ctx context.Context, id int) (string, error) {
+	entry, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return s.replay(ctx, entry)
+}
+
+func (s *deadLetterService) ReplayDeadLetters(ctx context.Context, req models.DeadLetterReplayRequest) (map[int]string, error) {
+	var entries []models.DeadLetterJob
+
+	if req.All {
+		found, _, err := s.repo.FindAll(ctx, repository.DeadLetterFilter{
+			JobType:        req.JobType,
+			OnlyUnreplayed: true,
+			Limit:          maxDeadLetterReplayBatch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = found
+	} else {
+		if len(req.IDs) == 0 {
+			return nil, fmt.Errorf("no dead-letter jobs selected for replay")
+		}
+		for _, id := range req.IDs {
+			entry, err := s.repo.FindByID(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("dead-letter job %d: %w", id, err)
+			}
+			entries = append(entries, *entry)
+		}
+	}
+
+	jobIDs := make(map[int]string, len(entries))
+	var errs []error
+	for i := range entries {
+		jobID, err := s.replay(ctx, &entries[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dead-letter job %d: %w", entries[i].ID, err))
+			continue
+		}
+		jobIDs[entries[i].ID] = jobID
+	}
+
+	return jobIDs, errors.Join(errs...)
+}
+
+func (s *deadLetterService) DeleteDeadLetter(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *deadLetterService) PurgeDeadLetters(ctx context.Context, filter repository.DeadLetterFilter) (int64, error) {
+	purged, err := s.repo.Purge(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.InfoContext(ctx, "Purged dead-letter jobs",
+		slog.Int64("purged", purged),
+		slog.String("job_type", filter.JobType))
+
+	return purged, nil
+}
+
+func (s *deadLetterService) replay(ctx context.Context, entry *models.DeadLetterJob) (string, error) {
+	jobType := worker.JobType(entry.JobType)
+
+	payload, err := decodeJobPayload(jobType, entry.Payload)
+	if err != nil {
+		return "", err
+	}
+
+	priority := worker.JobPriority(entry.Priority)
+	if priority == "" {
+		priority = worker.PriorityLow
+	}
+
+	job := worker.Job{
+		ID:        fmt.Sprintf("%s_replay_%d", entry.JobID, time.Now().UnixNano()),
+		Type:      jobType,
+		Priority:  priority,
+		Payload:   payload,
+		MaxRetry:  entry.MaxRetry,
+		CreatedAt: time.Now(),
+	}
+
+	_, span := startEnqueueSpan(ctx, &job)
+	err = s.workerPool.AddJob(job)
+	endSpan(span, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue replayed job: %w", err)
+	}
+
+	if err := s.repo.MarkReplayed(ctx, entry.ID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to mark dead-letter job replayed",
+			slog.Int("dead_letter_id", entry.ID),
+			slog.String("job_id", job.ID),
+			slog.String("error", err.Error()))
+	}
+
+	s.logger.InfoContext(ctx, "Replayed dead-letter job",
+		slog.Int("dead_letter_id", entry.ID),
+		slog.String("original_job_id", entry.JobID),
+		slog.String("job_id", job.ID))
+
+	return job.ID, nil
+}
+
+func decodeJobPayload(jobType worker.JobType, raw json.RawMessage) (interface{}, error) {
+	switch jobType {
+	case worker.JobTypeAnalyzeURL:
+		var urlID int
+		if err := json.Unmarshal(raw, &urlID); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", jobType, err)
+		}
+		return urlID, nil
+	default:
+		if len(raw) == 0 {
+			return nil, nil
+		}
+		var payload interface{}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", jobType, err)
+		}
+		return payload, nil
+	}
+}
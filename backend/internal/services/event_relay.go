@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"searcher-app/internal/events"
+	"searcher-app/internal/repository"
+)
+
+const (
+	outboxBufferSize = 1000
+	outboxBatchSize  = 100
+	relayBatchSize   = 500
+)
+
+// EventOutbox stores the events published on a worker process's bus in the
+// job_events table, where the API process's EventRelay picks them up for its
+// WebSocket clients and other subscribers.
+type EventOutbox struct {
+	repo   repository.JobEventRepository
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan json.RawMessage
+	done   chan struct{}
+}
+
+func NewEventOutbox(repo repository.JobEventRepository, bus *events.Bus, logger *slog.Logger) *EventOutbox {
+	outbox := &EventOutbox{
+		repo:   repo,
+		logger: logger,
+		queue:  make(chan json.RawMessage, outboxBufferSize),
+		done:   make(chan struct{}),
+	}
+	bus.Subscribe(outbox.handleEvent)
+
+	go outbox.run()
+
+	return outbox
+}
+
+// handleEvent runs on the publisher's goroutine, so the write is left to run
+// and an event that does not fit the buffer is dropped rather than blocking
+// the job that published it.
+func (o *EventOutbox) handleEvent(event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		o.logger.Error("Failed to encode event for the outbox",
+			slog.String("type", string(event.Type)),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.closed {
+		return
+	}
+
+	select {
+	case o.queue <- data:
+	default:
+		o.logger.Warn("Event outbox full, dropping event",
+			slog.String("type", string(event.Type)),
+			slog.String("job_id", event.JobID))
+	}
+}
+
+func (o *EventOutbox) run() {
+	defer close(o.done)
+
+	for data := range o.queue {
+		batch := []json.RawMessage{data}
+	fill:
+		for len(batch) < outboxBatchSize {
+			select {
+			case data, ok := <-o.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, data)
+			default:
+				break fill
+			}
+		}
+
+		if err := o.repo.Append(context.Background(), batch); err != nil {
+			o.logger.Error("Failed to store events",
+				slog.Int("events", len(batch)),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Close stores the events still buffered and stops the outbox. Events
+// published afterwards are dropped.
+func (o *EventOutbox) Close() {
+	o.mu.Lock()
+	if !o.closed {
+		o.closed = true
+		close(o.queue)
+	}
+	o.mu.Unlock()
+
+	<-o.done
+}
+
+type EventRelayConfig struct {
+	PollInterval time.Duration
+	// Retention is how long stored events are kept before the relay deletes
+	// them.
+	Retention time.Duration
+}
+
+// EventRelay publishes the events worker processes stored in job_events on
+// the API process's bus. It starts at the newest stored event, so events
+// from before the API started are not replayed.
+type EventRelay struct {
+	repo   repository.JobEventRepository
+	bus    *events.Bus
+	config EventRelayConfig
+	logger *slog.Logger
+
+	lastID  int64
+	started bool
+}
+
+func NewEventRelay(repo repository.JobEventRepository, bus *events.Bus, config EventRelayConfig, logger *slog.Logger) *EventRelay {
+	return &EventRelay{
+		repo:   repo,
+		bus:    bus,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (r *EventRelay) Start(ctx context.Context) {
+	go func() {
+		poll := time.NewTicker(r.config.PollInterval)
+		defer poll.Stop()
+
+		prune := time.NewTicker(r.config.Retention)
+		defer prune.Stop()
+
+		for {
+			select {
+			case <-poll.C:
+				if err := r.Relay(ctx); err != nil && ctx.Err() == nil {
+					r.logger.Error("Failed to relay worker events", slog.String("error", err.Error()))
+				}
+			case <-prune.C:
+				r.prune(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	r.logger.Info("Worker event relay started",
+		slog.Duration("poll_interval", r.config.PollInterval),
+		slog.Duration("retention", r.config.Retention))
+}
+
+// Relay publishes the events stored since the last call. Rows are read in ID
+// order; each is inserted on its own, so a row committed after a higher ID
+// was read is rare, and such an event is skipped.
+func (r *EventRelay) Relay(ctx context.Context) error {
+	if !r.started {
+		latest, err := r.repo.LatestID(ctx)
+		if err != nil {
+			return err
+		}
+		r.lastID = latest
+		r.started = true
+		return nil
+	}
+
+	for {
+		stored, err := r.repo.ListAfter(ctx, r.lastID, relayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range stored {
+			r.lastID = row.ID
+
+			var event events.Event
+			if err := json.Unmarshal(row.Event, &event); err != nil {
+				r.logger.Warn("Skipping unreadable worker event",
+					slog.Int64("event_id", row.ID),
+					slog.String("error", err.Error()))
+				continue
+			}
+			r.bus.Publish(event)
+		}
+
+		if len(stored) < relayBatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *EventRelay) prune(ctx context.Context) {
+	deleted, err := r.repo.DeleteOlderThan(ctx, r.config.Retention)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to delete old worker events", slog.String("error", err.Error()))
+		}
+		return
+	}
+	if deleted > 0 {
+		r.logger.Debug("Deleted old worker events", slog.Int64("events", deleted))
+	}
+}
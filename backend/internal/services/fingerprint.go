@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"searcher-app/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	simhashShingleSize    = 3
+	nearDuplicateDistance = 3
+
+	// Pages with fewer visible words, such as SPA shells and redirect stubs,
+	// get no simhash and are left out of duplicate detection.
+	minFingerprintWords = 20
+
+	// Two simhashes within nearDuplicateDistance bits agree on at least one
+	// of nearDuplicateDistance+1 bands, so only pages sharing a band need
+	// to be compared.
+	simhashBands    = nearDuplicateDistance + 1
+	simhashBandBits = 64 / simhashBands
+)
+
+var invisibleElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"head":     true,
+}
+
+func (s *enhancedCrawlerService) GetDuplicateGroups(ctx context.Context) ([]models.DuplicateGroup, error) {
+	fingerprints, err := s.urlRepo.FindContentFingerprints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve content fingerprints: %w", err)
+	}
+
+	return groupDuplicates(fingerprints), nil
+}
+
+// contentFingerprint returns the content hash used for change detection and
+// the simhash used for duplicate detection, which is empty for pages with too
+// little visible text to compare.
+func contentFingerprint(doc *html.Node) (string, string) {
+	words := visibleWords(doc)
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(words, " "))))
+	if len(words) < minFingerprintWords {
+		return hash, ""
+	}
+
+	return hash, fmt.Sprintf("%016x", simhash(words))
+}
+
+func visibleWords(doc *html.Node) []string {
+	var words []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && invisibleElements[strings.ToLower(n.Data)] {
+			return
+		}
+		if n.Type == html.CommentNode {
+			return
+		}
+		if n.Type == html.TextNode {
+			for _, word := range strings.Fields(strings.ToLower(n.Data)) {
+				words = append(words, word)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return words
+}
+
+func simhash(words []string) uint64 {
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	addFeature := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(words) < simhashShingleSize {
+		addFeature(strings.Join(words, " "))
+	} else {
+		for i := 0; i+simhashShingleSize <= len(words); i++ {
+			addFeature(strings.Join(words[i:i+simhashShingleSize], " "))
+		}
+	}
+
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+	return fingerprint
+}
+
+func groupDuplicates(fingerprints []models.ContentFingerprint) []models.DuplicateGroup {
+	parent := make([]int, len(fingerprints))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	// Identical content hashes and identical simhashes are unioned directly;
+	// only distinct simhashes go through band bucketing.
+	byHash := make(map[string]int)
+	bySimhash := make(map[uint64]int)
+	var distinct []uint64
+	for i, fp := range fingerprints {
+		if first, ok := byHash[fp.ContentHash]; ok {
+			union(first, i)
+		} else {
+			byHash[fp.ContentHash] = i
+		}
+
+		value, err := strconv.ParseUint(fp.ContentSimhash, 16, 64)
+		if err != nil {
+			continue
+		}
+		if first, ok := bySimhash[value]; ok {
+			union(first, i)
+			continue
+		}
+		bySimhash[value] = i
+		distinct = append(distinct, value)
+	}
+
+	type bandKey struct {
+		band  int
+		value uint64
+	}
+	buckets := make(map[bandKey][]uint64)
+	for _, value := range distinct {
+		for band := 0; band < simhashBands; band++ {
+			key := bandKey{band: band, value: (value >> (band * simhashBandBits)) & (1<<simhashBandBits - 1)}
+			buckets[key] = append(buckets[key], value)
+		}
+	}
+
+	for _, bucket := range buckets {
+		for i := 0; i < len(bucket); i++ {
+			for j := i + 1; j < len(bucket); j++ {
+				if bits.OnesCount64(bucket[i]^bucket[j]) <= nearDuplicateDistance {
+					union(bySimhash[bucket[i]], bySimhash[bucket[j]])
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var order []int
+	for i := range fingerprints {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	groups := []models.DuplicateGroup{}
+	for _, root := range order {
+		indexes := members[root]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		kind := "exact"
+		group := models.DuplicateGroup{Pages: make([]models.ContentFingerprint, 0, len(indexes))}
+		for _, i := range indexes {
+			if fingerprints[i].ContentHash != fingerprints[indexes[0]].ContentHash {
+				kind = "near"
+			}
+			group.Pages = append(group.Pages, fingerprints[i])
+		}
+		group.Kind = kind
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"searcher-app/internal/requestid"
+	"searcher-app/internal/worker"
+)
+
+// attachJobContext records the trace context and request ID of ctx in the job
+// metadata.
+func attachJobContext(ctx context.Context, job *worker.Job) {
+	worker.InjectTraceContext(ctx, job)
+	if id := requestid.FromContext(ctx); id != "" {
+		job.Metadata[worker.MetadataRequestID] = id
+	}
+}
+
+// jobContext restores the request ID recorded in the job metadata, so log
+// lines written while the job runs name the request that queued it.
+func jobContext(ctx context.Context, job worker.Job) context.Context {
+	return requestid.NewContext(ctx, job.Metadata[worker.MetadataRequestID])
+}
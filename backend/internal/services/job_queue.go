@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/worker"
+)
+
+type durableJobQueue struct {
+	repo repository.JobQueueRepository
+}
+
+// NewDurableJobQueue stores worker jobs in the job_queue table so API and
+// worker processes on different machines can share them.
+func NewDurableJobQueue(repo repository.JobQueueRepository) worker.DurableQueue {
+	return &durableJobQueue{repo: repo}
+}
+
+func (q *durableJobQueue) Enqueue(ctx context.Context, job worker.Job) error {
+	queued, err := newQueuedJob(job)
+	if err != nil {
+		return err
+	}
+	return q.repo.Enqueue(ctx, queued)
+}
+
+func (q *durableJobQueue) EnqueueCoalesced(ctx context.Context, job worker.Job) (string, error) {
+	queued, err := newQueuedJob(job)
+	if err != nil {
+		return "", err
+	}
+	if err := q.repo.EnqueueCoalesced(ctx, queued); err != nil {
+		return "", err
+	}
+	return queued.JobID, nil
+}
+
+func newQueuedJob(job worker.Job) (*models.QueuedJob, error) {
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	var metadata []byte
+	if len(job.Metadata) > 0 {
+		if metadata, err = json.Marshal(job.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to encode job metadata: %w", err)
+		}
+	}
+
+	return &models.QueuedJob{
+		JobID:    job.ID,
+		JobType:  string(job.Type),
+		Key:      job.Key,
+		Priority: string(job.Priority),
+		Payload:  payload,
+		Metadata: metadata,
+		MaxRetry: job.MaxRetry,
+	}, nil
+}
+
+func (q *durableJobQueue) Get(ctx context.Context, jobID string) (worker.JobRecord, error) {
+	queued, err := q.repo.Get(ctx, jobID)
+	if err != nil {
+		return worker.JobRecord{}, queueError(err)
+	}
+	return queuedJobRecord(queued), nil
+}
+
+func (q *durableJobQueue) Cancel(ctx context.Context, jobID string) (worker.JobRecord, error) {
+	queued, err := q.repo.Cancel(ctx, jobID)
+	if queued == nil {
+		return worker.JobRecord{}, queueError(err)
+	}
+	return queuedJobRecord(queued), queueError(err)
+}
+
+func (q *durableJobQueue) CancelKey(ctx context.Context, key string) ([]worker.JobRecord, error) {
+	queued, err := q.repo.CancelByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]worker.JobRecord, len(queued))
+	for i := range queued {
+		recs[i] = queuedJobRecord(&queued[i])
+	}
+	return recs, nil
+}
+
+// queuedJobStates maps job_queue statuses to the states the pool reports.
+var queuedJobStates = map[string]worker.JobState{
+	"pending":   worker.JobStateQueued,
+	"leased":    worker.JobStateRunning,
+	"succeeded": worker.JobStateSucceeded,
+	"failed":    worker.JobStateFailed,
+	"cancelled": worker.JobStateCancelled,
+}
+
+func queuedJobRecord(queued *models.QueuedJob) worker.JobRecord {
+	jobType := worker.JobType(queued.JobType)
+	state := queuedJobStates[queued.Status]
+
+	// A payload that cannot be decoded fails the job when it is leased; the
+	// record is still worth showing.
+	payload, _ := decodeJobPayload(jobType, queued.Payload)
+
+	rec := worker.JobRecord{
+		ID:              queued.JobID,
+		Type:            jobType,
+		State:           state,
+		Priority:        worker.JobPriority(queued.Priority),
+		Payload:         payload,
+		Attempts:        queued.Attempts,
+		MaxRetry:        queued.MaxRetry,
+		Error:           queued.LastError,
+		CreatedAt:       queued.CreatedAt,
+		LastHeartbeatAt: queued.HeartbeatAt,
+	}
+	if state.IsFinal() {
+		finishedAt := queued.UpdatedAt
+		rec.FinishedAt = &finishedAt
+	}
+	return rec
+}
+
+func (q *durableJobQueue) Lease(ctx context.Context, owner string, jobTypes []worker.JobType, lease time.Duration) (*worker.Job, error) {
+	types := make([]string, len(jobTypes))
+	for i, jobType := range jobTypes {
+		types[i] = string(jobType)
+	}
+
+	queued, err := q.repo.Lease(ctx, owner, types, lease)
+	if err != nil || queued == nil {
+		return nil, err
+	}
+
+	jobType := worker.JobType(queued.JobType)
+	payload, err := decodeJobPayload(jobType, queued.Payload)
+	if err != nil {
+		failCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		q.repo.Fail(failCtx, queued.JobID, owner, err.Error())
+		return nil, err
+	}
+
+	// Metadata only carries tracing and logging context, so a job whose
+	// metadata cannot be read still runs.
+	var metadata map[string]string
+	if len(queued.Metadata) > 0 {
+		json.Unmarshal(queued.Metadata, &metadata)
+	}
+
+	return &worker.Job{
+		ID:        queued.JobID,
+		Type:      jobType,
+		Key:       queued.Key,
+		Priority:  worker.JobPriority(queued.Priority),
+		Payload:   payload,
+		Metadata:  metadata,
+		MaxRetry:  queued.MaxRetry,
+		CreatedAt: queued.CreatedAt,
+	}, nil
+}
+
+func (q *durableJobQueue) Heartbeat(ctx context.Context, jobID, owner string, lease time.Duration) error {
+	return leaseError(q.repo.ExtendLease(ctx, jobID, owner, lease))
+}
+
+func (q *durableJobQueue) Complete(ctx context.Context, jobID, owner string) error {
+	return leaseError(q.repo.Complete(ctx, jobID, owner))
+}
+
+func (q *durableJobQueue) Fail(ctx context.Context, jobID, owner string, jobErr error) error {
+	return leaseError(q.repo.Fail(ctx, jobID, owner, jobErr.Error()))
+}
+
+func (q *durableJobQueue) Release(ctx context.Context, jobID, owner string) error {
+	return leaseError(q.repo.Release(ctx, jobID, owner))
+}
+
+func (q *durableJobQueue) ReleaseExpired(ctx context.Context) (int64, error) {
+	return q.repo.ReleaseExpired(ctx)
+}
+
+func leaseError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrLeaseLost):
+		return worker.ErrLeaseLost
+	case errors.Is(err, repository.ErrQueuedJobCancelled):
+		return worker.ErrJobCancelled
+	default:
+		return err
+	}
+}
+
+func queueError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrQueuedJobNotFound):
+		return worker.ErrJobNotFound
+	case errors.Is(err, repository.ErrQueuedJobFinished):
+		return worker.ErrJobFinished
+	default:
+		return err
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/worker"
+)
+
+func TestQueuedJobRecord(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status       string
+		want         worker.JobState
+		wantFinished bool
+	}{
+		{"pending", worker.JobStateQueued, false},
+		{"leased", worker.JobStateRunning, false},
+		{"succeeded", worker.JobStateSucceeded, true},
+		{"failed", worker.JobStateFailed, true},
+		{"cancelled", worker.JobStateCancelled, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			rec := queuedJobRecord(&models.QueuedJob{
+				JobID:     "analyze_42_1",
+				JobType:   string(worker.JobTypeAnalyzeURL),
+				Priority:  string(worker.PriorityHigh),
+				Payload:   json.RawMessage("42"),
+				Status:    tt.status,
+				UpdatedAt: updatedAt,
+			})
+
+			if rec.State != tt.want {
+				t.Errorf("State = %q, want %q", rec.State, tt.want)
+			}
+			if urlID, ok := rec.Payload.(int); !ok || urlID != 42 {
+				t.Errorf("Payload = %#v, want the URL ID 42", rec.Payload)
+			}
+			if finished := rec.FinishedAt != nil; finished != tt.wantFinished {
+				t.Errorf("FinishedAt = %v, want it set: %v", rec.FinishedAt, tt.wantFinished)
+			}
+		})
+	}
+}
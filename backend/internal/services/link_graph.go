@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"searcher-app/internal/models"
+)
+
+const (
+	maxLinkURLLength    = 2048
+	maxAnchorTextLength = 500
+	maxRelLength        = 255
+)
+
+func (s *enhancedCrawlerService) GetLinks(ctx context.Context, urlID int) ([]models.Link, error) {
+	if urlID <= 0 {
+		return nil, fmt.Errorf("invalid URL ID: %d", urlID)
+	}
+
+	links, err := s.urlRepo.FindLinksByURLID(ctx, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve links: %w", err)
+	}
+
+	return links, nil
+}
+
+func (s *enhancedCrawlerService) GetSiteLinkAnalysis(ctx context.Context, host string) (*models.SiteLinkAnalysis, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	pages, err := s.urlRepo.FindURLsByHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve site pages: %w", err)
+	}
+
+	links, err := s.urlRepo.FindInternalLinksByHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve site links: %w", err)
+	}
+
+	return buildSiteLinkAnalysis(host, pages, links), nil
+}
+
+func buildSiteLinkAnalysis(host string, pages []models.URL, links []models.Link) *models.SiteLinkAnalysis {
+	analysis := &models.SiteLinkAnalysis{
+		Host:                host,
+		Pages:               make([]models.PageLinkStats, 0, len(pages)),
+		OrphanPages:         []int{},
+		NoInternalOutlinks:  []int{},
+		UnreachableFromRoot: []int{},
+	}
+
+	pageByKey := make(map[string]int, len(pages))
+	keyByID := make(map[int]string, len(pages))
+	for _, page := range pages {
+		key := normalizeLinkURL(page.URL)
+		if key == "" {
+			continue
+		}
+		if _, exists := pageByKey[key]; !exists {
+			pageByKey[key] = page.ID
+		}
+		keyByID[page.ID] = key
+	}
+
+	outlinks := make(map[int]map[int]bool)
+	inlinks := make(map[int]map[int]bool)
+	internalOutlinks := make(map[int]map[string]bool)
+
+	for _, link := range links {
+		sourceKey, ok := keyByID[link.URLID]
+		if !ok {
+			continue
+		}
+		targetKey := normalizeLinkURL(link.TargetURL)
+		if targetKey == "" || targetKey == sourceKey {
+			continue
+		}
+
+		if internalOutlinks[link.URLID] == nil {
+			internalOutlinks[link.URLID] = make(map[string]bool)
+		}
+		internalOutlinks[link.URLID][targetKey] = true
+
+		targetID, ok := pageByKey[targetKey]
+		if !ok || targetID == link.URLID {
+			continue
+		}
+		if outlinks[link.URLID] == nil {
+			outlinks[link.URLID] = make(map[int]bool)
+		}
+		outlinks[link.URLID][targetID] = true
+		if inlinks[targetID] == nil {
+			inlinks[targetID] = make(map[int]bool)
+		}
+		inlinks[targetID][link.URLID] = true
+	}
+
+	rootID, hasRoot := findRootPage(pages)
+	depths := make(map[int]int)
+	if hasRoot {
+		analysis.RootURLID = &rootID
+		depths[rootID] = 0
+		queue := []int{rootID}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			targets := make([]int, 0, len(outlinks[current]))
+			for target := range outlinks[current] {
+				targets = append(targets, target)
+			}
+			sort.Ints(targets)
+
+			for _, target := range targets {
+				if _, seen := depths[target]; seen {
+					continue
+				}
+				depths[target] = depths[current] + 1
+				if depths[target] > analysis.MaxClickDepth {
+					analysis.MaxClickDepth = depths[target]
+				}
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	for _, page := range pages {
+		if _, ok := keyByID[page.ID]; !ok {
+			continue
+		}
+
+		stats := models.PageLinkStats{
+			URLID:            page.ID,
+			URL:              page.URL,
+			Inlinks:          len(inlinks[page.ID]),
+			InternalOutlinks: len(internalOutlinks[page.ID]),
+		}
+
+		if depth, ok := depths[page.ID]; ok {
+			d := depth
+			stats.ClickDepth = &d
+		} else if hasRoot {
+			analysis.UnreachableFromRoot = append(analysis.UnreachableFromRoot, page.ID)
+		}
+
+		isRoot := hasRoot && page.ID == rootID
+		if stats.Inlinks == 0 && !isRoot {
+			stats.IsOrphan = true
+			analysis.OrphanPages = append(analysis.OrphanPages, page.ID)
+		}
+
+		if page.Status == models.StatusCompleted && stats.InternalOutlinks == 0 {
+			stats.HasNoInternalOutlinks = true
+			analysis.NoInternalOutlinks = append(analysis.NoInternalOutlinks, page.ID)
+		}
+
+		analysis.Pages = append(analysis.Pages, stats)
+	}
+
+	return analysis
+}
+
+func findRootPage(pages []models.URL) (int, bool) {
+	rootID := 0
+	found := false
+	for _, page := range pages {
+		parsed, err := url.Parse(page.URL)
+		if err != nil {
+			continue
+		}
+		if (parsed.Path == "" || parsed.Path == "/") && parsed.RawQuery == "" {
+			if !found || page.ID < rootID {
+				rootID = page.ID
+				found = true
+			}
+		}
+	}
+	return rootID, found
+}
+
+func normalizeLinkURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	return parsed.String()
+}
+
+func truncateRunes(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:max])
+}
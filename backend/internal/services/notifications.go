@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"searcher-app/internal/events"
+	"searcher-app/internal/models"
+	"searcher-app/internal/worker"
+)
+
+func (s *enhancedCrawlerService) publishJobResult(result worker.JobResult) {
+	if result.Job.Type != worker.JobTypeAnalyzeURL {
+		return
+	}
+
+	urlID, ok := result.Job.Payload.(int)
+	if !ok {
+		return
+	}
+
+	event := events.Event{
+		Type:    events.TypeStatusUpdate,
+		URLID:   urlID,
+		JobID:   result.Job.ID,
+		JobType: string(result.Job.Type),
+	}
+
+	if result.Error == nil {
+		if url, ok := result.Data.(*models.URL); ok {
+			event.Status = string(url.Status)
+			event.Data = url
+			s.events.Publish(event)
+			return
+		}
+	}
+
+	if errors.Is(result.Error, context.Canceled) {
+		event.Status = string(models.StatusCancelled)
+		s.events.Publish(event)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url, err := s.urlRepo.FindByID(ctx, urlID)
+	if err != nil {
+		s.logger.Warn("Failed to load URL for job result notification",
+			slog.Int("url_id", urlID),
+			slog.String("job_id", result.Job.ID),
+			slog.String("error", err.Error()))
+
+		event.Status = string(models.StatusError)
+		if result.Error != nil {
+			event.Data = map[string]string{"error": result.Error.Error()}
+		}
+		s.events.Publish(event)
+		return
+	}
+
+	event.Status = string(url.Status)
+	event.Data = url
+	s.events.Publish(event)
+}
+
+func (s *enhancedCrawlerService) publishJobProgress(job worker.Job, progress worker.Progress) {
+	urlID, _ := job.Payload.(int)
+
+	s.events.Publish(events.Event{
+		Type:    events.TypeJobProgress,
+		URLID:   urlID,
+		Status:  progress.Phase,
+		JobID:   job.ID,
+		JobType: string(job.Type),
+		Data: map[string]interface{}{
+			"job_id":   job.ID,
+			"job_type": job.Type,
+			"progress": progress,
+		},
+	})
+}
+
+func (s *enhancedCrawlerService) publishStatus(urlID int, status models.URLStatus, job worker.Job) {
+	s.events.Publish(events.Event{
+		Type:    events.TypeStatusUpdate,
+		URLID:   urlID,
+		Status:  string(status),
+		JobID:   job.ID,
+		JobType: string(job.Type),
+	})
+}
+
+func (s *enhancedCrawlerService) publishBatchFinished(batchID string, results []worker.JobResult) {
+	summary := worker.SummarizeBatch(results)
+
+	failedURLs := make([]int, 0, summary.Failed)
+	for _, result := range results {
+		if result.Error == nil || errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, worker.ErrPoolDraining) ||
+			errors.Is(result.Error, worker.ErrJobForwarded) {
+			continue
+		}
+		if urlID, ok := result.Job.Payload.(int); ok {
+			failedURLs = append(failedURLs, urlID)
+		}
+	}
+
+	message := fmt.Sprintf("batch finished: %d ok, %d failed", summary.Succeeded, summary.Failed)
+	if summary.Cancelled > 0 {
+		message += fmt.Sprintf(", %d cancelled", summary.Cancelled)
+	}
+	if summary.Interrupted > 0 {
+		message += fmt.Sprintf(", %d interrupted by shutdown", summary.Interrupted)
+	}
+	if summary.Forwarded > 0 {
+		message += fmt.Sprintf(", %d handed to distributed workers", summary.Forwarded)
+	}
+
+	s.events.Publish(events.Event{
+		Type:    events.TypeBatchDone,
+		Status:  "finished",
+		JobID:   batchID,
+		JobType: string(worker.JobTypeAnalyzeURL),
+		Data: map[string]interface{}{
+			"batch_id":    batchID,
+			"summary":     summary,
+			"failed_urls": failedURLs,
+			"message":     message,
+		},
+	})
+}
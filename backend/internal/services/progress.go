@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"searcher-app/internal/worker"
+)
+
+const (
+	phaseFetching      = "fetching"
+	phaseParsing       = "parsing"
+	phaseCheckingLinks = "checking_links"
+	phaseSaving        = "saving"
+)
+
+func reportPhase(ctx context.Context, phase string) {
+	worker.ReportProgress(ctx, worker.Progress{Phase: phase})
+}
+
+func reportLinkProgress(ctx context.Context, done, total int) {
+	worker.ReportProgress(ctx, worker.Progress{
+		Phase: phaseCheckingLinks,
+		Done:  done,
+		Total: total,
+	})
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/worker"
+)
+
+type JobRecoveryService interface {
+	PersistUnfinished(ctx context.Context, jobs []worker.Job) error
+	ResetInterrupted(ctx context.Context, jobs []worker.Job)
+	RecoverPersisted(ctx context.Context, jobTypes ...worker.JobType) (int, error)
+}
+
+type jobRecoveryService struct {
+	urlRepo    repository.URLRepository
+	queue      worker.DurableQueue
+	workerPool *worker.WorkerPool
+	logger     *slog.Logger
+}
+
+func NewJobRecoveryService(urlRepo repository.URLRepository, queue worker.DurableQueue, workerPool *worker.WorkerPool, logger *slog.Logger) JobRecoveryService {
+	return &jobRecoveryService{
+		urlRepo:    urlRepo,
+		queue:      queue,
+		workerPool: workerPool,
+		logger:     logger,
+	}
+}
+
+// PersistUnfinished stores jobs a draining pool could not finish in the
+// durable queue, where RecoverPersisted or a distributed worker picks them up
+// again, and puts their URLs back to queued.
+func (s *jobRecoveryService) PersistUnfinished(ctx context.Context, jobs []worker.Job) error {
+	var errs []error
+	persisted := 0
+	for _, job := range jobs {
+		job.Retry = 0
+		job.History = nil
+		if err := s.queue.Enqueue(ctx, job); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+			continue
+		}
+		persisted++
+	}
+
+	s.ResetInterrupted(ctx, jobs)
+
+	s.logger.Info("Persisted unfinished jobs",
+		slog.Int("persisted", persisted),
+		slog.Int("failed", len(errs)))
+
+	return errors.Join(errs...)
+}
+
+// ResetInterrupted moves URLs whose analysis was stopped midway from
+// processing back to queued so they do not linger as zombies.
+func (s *jobRecoveryService) ResetInterrupted(ctx context.Context, jobs []worker.Job) {
+	for _, job := range jobs {
+		if job.Type != worker.JobTypeAnalyzeURL {
+			continue
+		}
+		urlID, ok := job.Payload.(int)
+		if !ok {
+			continue
+		}
+
+		if err := s.urlRepo.UpdateStatus(ctx, urlID, models.StatusQueued, nil); err != nil {
+			s.logger.Error("Failed to reset status of interrupted analysis",
+				slog.Int("url_id", urlID),
+				slog.String("job_id", job.ID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// RecoverPersisted moves persisted jobs of the given types from the durable
+// queue into the local pool, for processes that do not run distributed workers.
+func (s *jobRecoveryService) RecoverPersisted(ctx context.Context, jobTypes ...worker.JobType) (int, error) {
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("recovery-%s-%d", hostname, os.Getpid())
+
+	recovered := 0
+	for {
+		job, err := s.queue.Lease(ctx, owner, jobTypes, time.Minute)
+		if err != nil {
+			return recovered, fmt.Errorf("failed to lease persisted job: %w", err)
+		}
+		if job == nil {
+			break
+		}
+
+		if err := s.workerPool.AddJobWithTimeout(*job, 30*time.Second); err != nil {
+			if releaseErr := s.queue.Release(ctx, job.ID, owner); releaseErr != nil {
+				s.logger.Error("Failed to release persisted job",
+					slog.String("job_id", job.ID),
+					slog.String("error", releaseErr.Error()))
+			}
+			return recovered, fmt.Errorf("failed to queue persisted job %s: %w", job.ID, err)
+		}
+
+		if err := s.queue.Complete(ctx, job.ID, owner); err != nil {
+			s.logger.Warn("Failed to mark persisted job recovered",
+				slog.String("job_id", job.ID),
+				slog.String("error", err.Error()))
+		}
+		recovered++
+	}
+
+	if recovered > 0 {
+		s.logger.Info("Recovered persisted jobs", slog.Int("jobs", recovered))
+	}
+
+	return recovered, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"searcher-app/internal/worker"
+)
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+	retryAfter time.Duration
+}
+
+func newHTTPStatusError(resp *http.Response) *httpStatusError {
+	return &httpStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d %s", e.StatusCode, e.Status)
+}
+
+func (e *httpStatusError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e *httpStatusError) Temporary() bool {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests, e.StatusCode == http.StatusRequestTimeout:
+		return true
+	case e.StatusCode >= 500:
+		return e.StatusCode != http.StatusNotImplemented && e.StatusCode != http.StatusHTTPVersionNotSupported
+	default:
+		return false
+	}
+}
+
+func crawlRetryPolicy(config *CrawlerConfig) worker.RetryPolicy {
+	policy := worker.DefaultRetryPolicy
+	if config.RetryDelay > 0 {
+		policy.BaseDelay = config.RetryDelay
+	}
+	policy.MaxDelay = 2 * time.Minute
+	policy.Retryable = isRetryableCrawlError
+	return policy
+}
+
+// isRetryableCrawlError treats HTTP status failures by code and other fetch
+// failures (timeouts, resets, DNS hiccups) as transient, except those the same
+// request runs into again. Errors known to be permanent where they are
+// produced are wrapped with worker.Permanent there.
+func isRetryableCrawlError(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return !isPermanentFetchError(err)
+}
+
+// isPermanentFetchError recognises redirect loops, hosts that do not exist,
+// certificates that fail verification and URLs the client cannot fetch.
+func isPermanentFetchError(err error) bool {
+	if errors.Is(err, errTooManyRedirects) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &verifyErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+
+	// net/http reports a missing or unsupported scheme, also one reached
+	// through a redirect, as a plain error.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && strings.Contains(urlErr.Err.Error(), "unsupported protocol scheme")
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func fetchError(t *testing.T, client *http.Client, target string) error {
+	t.Helper()
+
+	resp, err := client.Get(target)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("GET %s succeeded, want an error", target)
+	}
+	return fmt.Errorf("failed to fetch URL: %w", err)
+}
+
+func TestIsRetryableCrawlError(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	redirects := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ftp":
+			http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer redirects.Close()
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errTooManyRedirects
+			}
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &httpStatusError{StatusCode: http.StatusBadGateway}, true},
+		{"too many requests", &httpStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"request timeout", &httpStatusError{StatusCode: http.StatusRequestTimeout}, true},
+		{"not implemented", &httpStatusError{StatusCode: http.StatusNotImplemented}, false},
+		{"not found", &httpStatusError{StatusCode: http.StatusNotFound}, false},
+		{"wrapped status", fmt.Errorf("crawl: %w", &httpStatusError{StatusCode: http.StatusForbidden}), false},
+		{"connection reset", fmt.Errorf("failed to fetch URL: %w", errors.New("connection reset by peer")), true},
+		{"deadline exceeded", fmt.Errorf("failed to fetch URL: %w", context.DeadlineExceeded), true},
+		{"host not found", fmt.Errorf("failed to fetch URL: %w", &net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true}), false},
+		{"dns timeout", fmt.Errorf("failed to fetch URL: %w", &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}), true},
+		{"missing scheme", fetchError(t, client, "example.com/page"), false},
+		{"unsupported scheme", fetchError(t, client, "gopher://example.com/"), false},
+		{"redirect to unsupported scheme", fetchError(t, client, redirects.URL+"/ftp"), false},
+		{"redirect loop", fetchError(t, client, redirects.URL+"/loop"), false},
+		{"untrusted certificate", fetchError(t, client, tlsServer.URL), false},
+		{"plain url error", &url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("EOF")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableCrawlError(tt.err); got != tt.want {
+				t.Errorf("isRetryableCrawlError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"blank", "   ", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"seconds with spaces", " 5 ", 5 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-10", 0},
+		{"http date", "Fri, 01 Mar 2024 12:01:30 GMT", 90 * time.Second},
+		{"http date in the past", "Fri, 01 Mar 2024 11:59:00 GMT", 0},
+		{"rfc 850 date", "Friday, 01-Mar-24 12:00:10 GMT", 10 * time.Second},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"searcher-app/internal/events"
+	"searcher-app/internal/models"
+	"searcher-app/internal/repository"
+	"searcher-app/internal/worker"
+)
+
+// FindStuckURLs lists URLs that have been processing for longer than
+// stuckAfter (the configured StuckAfter when zero) with neither an analysis
+// job queued or running in this process's pool nor a pending or leased row in
+// job_queue. Leased rows count even after their lease expired, since the lease
+// reaper hands them back to the queue.
+func (s *enhancedCrawlerService) FindStuckURLs(ctx context.Context, filter repository.URLFilter, stuckAfter time.Duration) ([]models.URL, int, error) {
+	if stuckAfter <= 0 {
+		stuckAfter = s.config.StuckAfter
+	}
+
+	cutoff := time.Now().Add(-stuckAfter)
+	filter.StuckBefore = &cutoff
+	filter.ExcludeIDs = s.liveAnalysisURLIDs()
+
+	return s.getURLs(ctx, filter)
+}
+
+func (s *enhancedCrawlerService) liveAnalysisURLIDs() []int {
+	var ids []int
+	for _, rec := range s.workerPool.ListJobs(worker.JobFilter{Type: worker.JobTypeAnalyzeURL}) {
+		if rec.State.IsFinal() {
+			continue
+		}
+		if id, ok := rec.Payload.(int); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type StuckURLReaper interface {
+	Start(ctx context.Context)
+	Reap(ctx context.Context) (requeued int, failed int, err error)
+}
+
+type ReaperConfig struct {
+	Interval    time.Duration `envconfig:"REAPER_INTERVAL" default:"5m"`
+	Requeue     bool          `envconfig:"REAPER_REQUEUE" default:"true"`
+	MaxRequeues int           `envconfig:"REAPER_MAX_REQUEUES" default:"2"`
+	BatchSize   int           `envconfig:"REAPER_BATCH_SIZE" default:"100"`
+}
+
+type stuckURLReaper struct {
+	crawler CrawlerService
+	urlRepo repository.URLRepository
+	events  *events.Bus
+	config  *ReaperConfig
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	requeues map[int]int
+}
+
+func NewStuckURLReaper(crawler CrawlerService, urlRepo repository.URLRepository, bus *events.Bus, config *ReaperConfig, logger *slog.Logger) StuckURLReaper {
+	reaper := &stuckURLReaper{
+		crawler:  crawler,
+		urlRepo:  urlRepo,
+		events:   bus,
+		config:   config,
+		logger:   logger,
+		requeues: make(map[int]int),
+	}
+	bus.Subscribe(reaper.handleEvent)
+
+	return reaper
+}
+
+func (r *stuckURLReaper) Start(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		r.logger.Info("Stuck URL reaper disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, _, err := r.Reap(ctx); err != nil && ctx.Err() == nil {
+					r.logger.Error("Stuck URL reaper failed", slog.String("error", err.Error()))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	r.logger.Info("Stuck URL reaper scheduled",
+		slog.Duration("interval", r.config.Interval),
+		slog.Bool("requeue", r.config.Requeue))
+}
+
+// Reap requeues stuck URLs, or marks them as error once they have been
+// requeued MaxRequeues times or requeueing is disabled.
+func (r *stuckURLReaper) Reap(ctx context.Context) (int, int, error) {
+	urls, _, err := r.crawler.FindStuckURLs(ctx, repository.URLFilter{Limit: r.config.BatchSize}, 0)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find stuck URLs: %w", err)
+	}
+
+	requeued, failed := 0, 0
+	for _, url := range urls {
+		if r.config.Requeue && r.takeRequeue(url.ID) {
+			err := r.requeue(ctx, url)
+			if err == nil {
+				requeued++
+				continue
+			}
+			r.logger.Warn("Failed to requeue stuck URL, marking it as error",
+				slog.Int("url_id", url.ID),
+				slog.String("error", err.Error()))
+		}
+
+		if err := r.markError(ctx, url); err != nil {
+			r.logger.Error("Failed to mark stuck URL as error",
+				slog.Int("url_id", url.ID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		failed++
+	}
+
+	if len(urls) > 0 {
+		r.logger.Info("Reaped stuck URLs",
+			slog.Int("found", len(urls)),
+			slog.Int("requeued", requeued),
+			slog.Int("marked_error", failed))
+	}
+
+	return requeued, failed, nil
+}
+
+func (r *stuckURLReaper) requeue(ctx context.Context, url models.URL) error {
+	if err := r.urlRepo.UpdateStatus(ctx, url.ID, models.StatusQueued, nil); err != nil {
+		return err
+	}
+	if _, err := r.crawler.AnalyzeURLWithContext(ctx, url.ID); err != nil {
+		return err
+	}
+
+	r.publish(url.ID, models.StatusQueued)
+	return nil
+}
+
+func (r *stuckURLReaper) markError(ctx context.Context, url models.URL) error {
+	msg := fmt.Sprintf("Analysis abandoned: processing since %s without a running job", url.UpdatedAt.Format(time.RFC3339))
+	if err := r.urlRepo.UpdateStatus(ctx, url.ID, models.StatusError, &msg); err != nil {
+		return err
+	}
+
+	r.publish(url.ID, models.StatusError)
+	return nil
+}
+
+func (r *stuckURLReaper) takeRequeue(urlID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requeues[urlID] >= r.config.MaxRequeues {
+		return false
+	}
+	r.requeues[urlID]++
+	return true
+}
+
+// handleEvent forgets the requeues of a URL once its analysis has ended, so a
+// URL that gets stuck again later is requeued rather than marked as error.
+func (r *stuckURLReaper) handleEvent(event events.Event) {
+	if event.Type != events.TypeStatusUpdate {
+		return
+	}
+	switch models.URLStatus(event.Status) {
+	case models.StatusQueued, models.StatusProcessing:
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.requeues, event.URLID)
+}
+
+func (r *stuckURLReaper) publish(urlID int, status models.URLStatus) {
+	r.events.Publish(events.Event{
+		Type:   events.TypeStatusUpdate,
+		URLID:  urlID,
+		Status: string(status),
+	})
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"searcher-app/internal/worker"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("searcher-app/internal/services")
+
+// startEnqueueSpan starts a producer span for queuing job and stores its
+// context in the job, so the job's run joins the caller's trace.
+func startEnqueueSpan(ctx context.Context, job *worker.Job) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "enqueue "+string(job.Type),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", string(job.Type)),
+			attribute.String("job.priority", string(job.Priority)),
+		))
+	attachJobContext(ctx, job)
+	return ctx, span
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
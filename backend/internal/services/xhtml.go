@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// parseXHTML reads a document served as XML with an XML tokenizer, the way a
+// browser does, so self-closing elements, CDATA sections and elements the
+// HTML parser would move end up where the author put them. The result has
+// the shape html.Parse produces, so the same analysis runs over both.
+func parseXHTML(body []byte) (*html.Node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	doc := &html.Node{Type: html.DocumentNode}
+	parent := doc
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XHTML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			node := &html.Node{Type: html.ElementNode, Data: name, DataAtom: atom.Lookup([]byte(name))}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				node.Attr = append(node.Attr, html.Attribute{
+					Namespace: attr.Name.Space,
+					Key:       strings.ToLower(attr.Name.Local),
+					Val:       attr.Value,
+				})
+			}
+			parent.AppendChild(node)
+			parent = node
+		case xml.EndElement:
+			if parent != doc {
+				parent = parent.Parent
+			}
+		case xml.CharData:
+			parent.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+		case xml.Comment:
+			parent.AppendChild(&html.Node{Type: html.CommentNode, Data: string(t)})
+		case xml.Directive:
+			if doctype := parseDoctype(string(t)); doctype != nil {
+				doc.AppendChild(doctype)
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+// parseDoctype turns a DOCTYPE directive into the node html.Parse builds for
+// it: the name in Data and the identifiers as public and system attributes.
+func parseDoctype(directive string) *html.Node {
+	fields := strings.Fields(directive)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "doctype") {
+		return nil
+	}
+
+	node := &html.Node{Type: html.DoctypeNode, Data: strings.ToLower(fields[1])}
+	rest := strings.TrimSpace(strings.Join(fields[2:], " "))
+	ids := quotedStrings(rest)
+
+	switch {
+	case len(rest) >= 6 && strings.EqualFold(rest[:6], "public"):
+		if len(ids) > 0 {
+			node.Attr = append(node.Attr, html.Attribute{Key: "public", Val: ids[0]})
+		}
+		if len(ids) > 1 {
+			node.Attr = append(node.Attr, html.Attribute{Key: "system", Val: ids[1]})
+		}
+	case len(rest) >= 6 && strings.EqualFold(rest[:6], "system"):
+		if len(ids) > 0 {
+			node.Attr = append(node.Attr, html.Attribute{Key: "system", Val: ids[0]})
+		}
+	}
+
+	return node
+}
+
+// quotedStrings returns the single- or double-quoted strings in s, in order.
+func quotedStrings(s string) []string {
+	var values []string
+	for {
+		start := strings.IndexAny(s, `"'`)
+		if start < 0 {
+			return values
+		}
+		end := strings.IndexByte(s[start+1:], s[start])
+		if end < 0 {
+			return values
+		}
+		values = append(values, s[start+1:start+1+end])
+		s = s[start+end+2:]
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// render prints the elements and text of a tree in document order, one node
+// per line, so tests can compare parse results without depending on the
+// whitespace html.Parse inserts.
+func render(n *html.Node) string {
+	var buf bytes.Buffer
+	var walk func(*html.Node, int)
+	walk = func(n *html.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch n.Type {
+		case html.DoctypeNode:
+			buf.WriteString(indent + "<!doctype " + n.Data)
+			for _, attr := range n.Attr {
+				buf.WriteString(" " + attr.Key + "=" + attr.Val)
+			}
+			buf.WriteString(">\n")
+		case html.ElementNode:
+			buf.WriteString(indent + "<" + n.Data)
+			for _, attr := range n.Attr {
+				buf.WriteString(" " + attr.Key + "=" + attr.Val)
+			}
+			buf.WriteString(">\n")
+		case html.TextNode:
+			if text := strings.TrimSpace(n.Data); text != "" {
+				buf.WriteString(indent + text + "\n")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, depth+1)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, 0)
+	}
+	return buf.String()
+}
+
+func TestParseXHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "document",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Page</title></head>
+<body><h1>Heading</h1><a href="/about">About</a></body></html>`,
+			want: "<html>\n  <head>\n    <title>\n      Page\n  <body>\n    <h1>\n      Heading\n    <a href=/about>\n      About\n",
+		},
+		{
+			name: "self-closing elements keep their siblings",
+			body: `<html><body><div/><p>After</p></body></html>`,
+			want: "<html>\n  <body>\n    <div>\n    <p>\n      After\n",
+		},
+		{
+			name: "elements stay where the author put them",
+			body: `<html><body><table><a href="/x">Link</a></table></body></html>`,
+			want: "<html>\n  <body>\n    <table>\n      <a href=/x>\n        Link\n",
+		},
+		{
+			name: "cdata",
+			body: `<html><body><p><![CDATA[a < b]]></p></body></html>`,
+			want: "<html>\n  <body>\n    <p>\n      a < b\n",
+		},
+		{
+			name: "html entities",
+			body: `<html><body><p>&copy; 2024&nbsp;&amp; more</p></body></html>`,
+			want: "<html>\n  <body>\n    <p>\n      © 2024 & more\n",
+		},
+		{
+			name: "upper case names",
+			body: `<HTML><BODY><A HREF="/x">Link</A></BODY></HTML>`,
+			want: "<html>\n  <body>\n    <a href=/x>\n      Link\n",
+		},
+		{
+			name: "namespace declarations dropped",
+			body: `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:svg="http://www.w3.org/2000/svg" lang="en"></html>`,
+			want: "<html lang=en>\n",
+		},
+		{
+			name: "unclosed void elements",
+			body: `<html><body><br><img src="a.png"><p>Text</p></body></html>`,
+			want: "<html>\n  <body>\n    <br>\n    <img src=a.png>\n    <p>\n      Text\n",
+		},
+		{
+			name: "byte order mark",
+			body: "\xef\xbb\xbf<html><head><title>Page</title></head></html>",
+			want: "<html>\n  <head>\n    <title>\n      Page\n",
+		},
+		{
+			name: "declared charset",
+			body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><html><body><p>Caf\xe9</p></body></html>",
+			want: "<html>\n  <body>\n    <p>\n      Café\n",
+		},
+		{
+			name: "public doctype",
+			body: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"><html></html>`,
+			want: "<!doctype html public=-//W3C//DTD XHTML 1.0 Strict//EN system=http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd>\n<html>\n",
+		},
+		{
+			name: "system doctype",
+			body: `<!DOCTYPE html SYSTEM "about:legacy-compat"><html></html>`,
+			want: "<!doctype html system=about:legacy-compat>\n<html>\n",
+		},
+		{
+			name: "bare doctype",
+			body: `<!doctype HTML><html></html>`,
+			want: "<!doctype html>\n<html>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseXHTML([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("parseXHTML: %v", err)
+			}
+			if got := render(doc); got != tt.want {
+				t.Errorf("parseXHTML produced\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXHTMLRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unterminated tag", `<html><body><p class="x`},
+		{"unknown charset", `<?xml version="1.0" encoding="no-such-charset"?><html></html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseXHTML([]byte(tt.body)); err == nil {
+				t.Error("parseXHTML succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestDetectHTMLVersion(t *testing.T) {
+	const (
+		xhtmlStrict = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`
+		xhtml11     = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`
+	)
+
+	tests := []struct {
+		name    string
+		doctype string
+		// prologue starts the document with an XML declaration.
+		prologue bool
+		want     string
+	}{
+		{"html5", `<!DOCTYPE html>`, false, "HTML5"},
+		{"legacy compat", `<!DOCTYPE html SYSTEM "about:legacy-compat">`, false, "HTML5"},
+		{"html 4.01 strict", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`, false, "HTML 4.01 Strict"},
+		{"html 4.01 transitional", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`, false, "HTML 4.01 Transitional"},
+		{"html 4.01 frameset", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd">`, false, "HTML 4.01 Frameset"},
+		{"xhtml 1.0 strict", xhtmlStrict, false, "XHTML 1.0 Strict"},
+		{"xhtml 1.0 transitional", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`, false, "XHTML 1.0 Transitional"},
+		{"xhtml 1.0 frameset", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Frameset//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd">`, false, "XHTML 1.0 Frameset"},
+		{"xhtml 1.1", xhtml11, false, "XHTML 1.1"},
+		{"xhtml 1.1 with prologue", xhtml11, true, "XHTML 1.1"},
+		{"xhtml basic", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML Basic 1.1//EN" "http://www.w3.org/TR/xhtml-basic/xhtml-basic11.dtd">`, false, "XHTML"},
+		{"html 3.2", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`, false, "HTML 4.01"},
+		{"no doctype", "", false, "HTML5"},
+		{"no doctype with prologue", "", true, "XHTML"},
+	}
+
+	s := &enhancedCrawlerService{}
+	parsers := []struct {
+		name  string
+		parse func([]byte) (*html.Node, error)
+	}{
+		{"html", func(body []byte) (*html.Node, error) { return html.Parse(bytes.NewReader(body)) }},
+		{"xhtml", parseXHTML},
+	}
+
+	for _, tt := range tests {
+		body := tt.doctype + `<html><head><title>Page</title></head><body></body></html>`
+		if tt.prologue {
+			body = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + body
+		}
+
+		for _, parser := range parsers {
+			t.Run(tt.name+"/"+parser.name, func(t *testing.T) {
+				doc, err := parser.parse([]byte(body))
+				if err != nil {
+					t.Fatalf("parse: %v", err)
+				}
+				if got := s.detectHTMLVersion(doc, hasXMLPrologue([]byte(body))); got != tt.want {
+					t.Errorf("detectHTMLVersion() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
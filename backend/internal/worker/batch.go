@@ -0,0 +1,278 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrJobForwarded is the result a batch records for a job forwarded to a
+// durable queue, which finishes in another process.
+var ErrJobForwarded = errors.New("job forwarded to a durable queue")
+
+type BatchJob struct {
+	ID       string
+	Jobs     []Job
+	Callback func(results []JobResult)
+}
+
+type BatchSummary struct {
+	Total       int `json:"total"`
+	Succeeded   int `json:"succeeded"`
+	Failed      int `json:"failed"`
+	Cancelled   int `json:"cancelled"`
+	Interrupted int `json:"interrupted"`
+	Forwarded   int `json:"forwarded"`
+}
+
+func SummarizeBatch(results []JobResult) BatchSummary {
+	summary := BatchSummary{Total: len(results)}
+	for _, result := range results {
+		switch {
+		case result.Error == nil:
+			summary.Succeeded++
+		case errors.Is(result.Error, ErrJobForwarded):
+			summary.Forwarded++
+		case errors.Is(result.Error, ErrPoolDraining):
+			summary.Interrupted++
+		case errors.Is(result.Error, context.Canceled):
+			summary.Cancelled++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+type batchRun struct {
+	batch     BatchJob
+	results   []JobResult
+	remaining int
+}
+
+type BatchWorkerPool struct {
+	*WorkerPool
+	batchSize int
+	batch     []Job
+	callback  func(results []JobResult)
+	mu        sync.Mutex
+
+	runMu   sync.Mutex
+	pending map[string][]*batchRun
+}
+
+func NewBatchWorkerPool(workerCount int, queueSize int, batchSize int, logger *slog.Logger) *BatchWorkerPool {
+	return WrapBatchWorkerPool(NewWorkerPool(workerCount, queueSize, logger), batchSize)
+}
+
+// WrapBatchWorkerPool adds batch tracking to an existing pool, so batches
+// share its workers, handlers and limits with individually queued jobs.
+func WrapBatchWorkerPool(wp *WorkerPool, batchSize int) *BatchWorkerPool {
+	bwp := &BatchWorkerPool{
+		WorkerPool: wp,
+		batchSize:  batchSize,
+		batch:      make([]Job, 0, batchSize),
+		pending:    make(map[string][]*batchRun),
+	}
+
+	wp.AddResultListener(bwp.handleResult)
+	wp.AddDrainHook(bwp.handleDrain)
+
+	return bwp
+}
+
+// SetBatchCallback sets the callback for batches assembled by AddJobToBatch.
+func (bwp *BatchWorkerPool) SetBatchCallback(callback func(results []JobResult)) {
+	bwp.mu.Lock()
+	defer bwp.mu.Unlock()
+	bwp.callback = callback
+}
+
+// SubmitBatch queues every job of the batch and calls its Callback once all
+// of them have finished, with one result per job. Jobs are coalesced with
+// equivalent pending jobs, so the returned IDs - one per job, empty when the
+// job was rejected - may name jobs queued earlier. Rejected jobs are reported
+// as failed results and returned keyed by job ID. Jobs forwarded to a durable
+// queue finish in another process; they are reported with ErrJobForwarded as
+// soon as they are queued, so a batch of forwarded jobs completes at once.
+func (bwp *BatchWorkerPool) SubmitBatch(batch BatchJob) ([]string, map[string]error) {
+	run := &batchRun{
+		batch:   batch,
+		results: make([]JobResult, 0, len(batch.Jobs)),
+	}
+	jobIDs := make([]string, len(batch.Jobs))
+
+	var rejected map[string]error
+	var settled []JobResult
+
+	// Results are matched by job ID, so the batch must be registered before
+	// a coalesced job that is already running can report back.
+	bwp.runMu.Lock()
+	for i, job := range batch.Jobs {
+		jobID, err := bwp.AddCoalescedJob(job)
+		if err != nil {
+			if rejected == nil {
+				rejected = make(map[string]error)
+			}
+			rejected[job.ID] = err
+			settled = append(settled, JobResult{Job: job, Error: err})
+			continue
+		}
+
+		jobIDs[i] = jobID
+		if _, forwarded := bwp.forwardQueue(job.Type); forwarded {
+			settled = append(settled, JobResult{Job: job, Error: ErrJobForwarded})
+			continue
+		}
+		run.remaining++
+		bwp.pending[jobID] = append(bwp.pending[jobID], run)
+	}
+	tracked := run.remaining
+	run.remaining += len(settled)
+	bwp.runMu.Unlock()
+
+	for _, result := range settled {
+		bwp.record(run, result)
+	}
+
+	bwp.logger.Info("Batch submitted",
+		slog.String("batch_id", batch.ID),
+		slog.Int("jobs", len(batch.Jobs)),
+		slog.Int("tracked", tracked),
+		slog.Int("rejected", len(rejected)))
+
+	if len(batch.Jobs) == 0 && batch.Callback != nil {
+		batch.Callback(nil)
+	}
+
+	return jobIDs, rejected
+}
+
+func (bwp *BatchWorkerPool) handleResult(result JobResult) {
+	bwp.runMu.Lock()
+	runs := bwp.pending[result.Job.ID]
+	delete(bwp.pending, result.Job.ID)
+	bwp.runMu.Unlock()
+
+	for _, run := range runs {
+		bwp.record(run, result)
+	}
+}
+
+// handleDrain hands back jobs still waiting in a partially filled batch and
+// completes every batch whose jobs were handed back by the drain, reporting
+// those jobs as interrupted with ErrPoolDraining.
+func (bwp *BatchWorkerPool) handleDrain(unfinished []Job) []Job {
+	bwp.mu.Lock()
+	waiting := bwp.batch
+	bwp.batch = make([]Job, 0, bwp.batchSize)
+	bwp.mu.Unlock()
+
+	// Finished jobs may still be on their way through the result queue, so
+	// only the jobs handed back are completed here.
+	var expired []JobResult
+	var runs [][]*batchRun
+	bwp.runMu.Lock()
+	for _, job := range unfinished {
+		if pending, ok := bwp.pending[job.ID]; ok {
+			delete(bwp.pending, job.ID)
+			expired = append(expired, JobResult{Job: job, Error: ErrPoolDraining})
+			runs = append(runs, pending)
+		}
+	}
+	bwp.runMu.Unlock()
+
+	for i, result := range expired {
+		for _, run := range runs[i] {
+			bwp.record(run, result)
+		}
+	}
+
+	return waiting
+}
+
+func (bwp *BatchWorkerPool) record(run *batchRun, result JobResult) {
+	bwp.runMu.Lock()
+	run.results = append(run.results, result)
+	run.remaining--
+	done := run.remaining == 0
+	bwp.runMu.Unlock()
+
+	if !done {
+		return
+	}
+
+	summary := SummarizeBatch(run.results)
+	bwp.logger.Info("Batch finished",
+		slog.String("batch_id", run.batch.ID),
+		slog.Int("succeeded", summary.Succeeded),
+		slog.Int("failed", summary.Failed),
+		slog.Int("cancelled", summary.Cancelled),
+		slog.Int("interrupted", summary.Interrupted),
+		slog.Int("forwarded", summary.Forwarded))
+
+	if run.batch.Callback != nil {
+		run.batch.Callback(run.results)
+	}
+}
+
+func (bwp *BatchWorkerPool) AddJobToBatch(job Job) error {
+	bwp.mu.Lock()
+	defer bwp.mu.Unlock()
+
+	bwp.batch = append(bwp.batch, job)
+
+	if len(bwp.batch) >= bwp.batchSize {
+		return bwp.processBatch()
+	}
+
+	return nil
+}
+
+func (bwp *BatchWorkerPool) processBatch() error {
+	if len(bwp.batch) == 0 {
+		return nil
+	}
+
+	batch := BatchJob{
+		ID:       fmt.Sprintf("batch_%d", time.Now().UnixNano()),
+		Jobs:     bwp.batch,
+		Callback: bwp.callback,
+	}
+	bwp.batch = make([]Job, 0, bwp.batchSize)
+
+	if _, rejected := bwp.SubmitBatch(batch); len(rejected) > 0 {
+		return fmt.Errorf("%d of %d jobs in batch %s could not be queued", len(rejected), len(batch.Jobs), batch.ID)
+	}
+	return nil
+}
+
+func (bwp *BatchWorkerPool) FlushBatch() error {
+	bwp.mu.Lock()
+	defer bwp.mu.Unlock()
+
+	return bwp.processBatch()
+}
+
+// StartFlusher submits a partially filled batch every interval so jobs added
+// with AddJobToBatch never wait indefinitely for the batch to fill up.
+func (bwp *BatchWorkerPool) StartFlusher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := bwp.FlushBatch(); err != nil {
+					bwp.logger.Error("Failed to flush batch", slog.String("error", err.Error()))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
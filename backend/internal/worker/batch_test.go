@@ -0,0 +1,311 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+)
+
+// batchResults collects the results a batch callback is called with.
+type batchResults chan []JobResult
+
+func (r batchResults) callback(results []JobResult) { r <- results }
+
+func (r batchResults) wait(t *testing.T) []JobResult {
+	t.Helper()
+
+	select {
+	case results := <-r:
+		return results
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch callback was not called")
+		return nil
+	}
+}
+
+// release lets jobs run until the batch callback is called.
+func (r batchResults) release(t *testing.T, handler *gatedHandler) []JobResult {
+	t.Helper()
+
+	deadline := time.After(2 * time.Second)
+	for {
+		handler.release()
+		select {
+		case results := <-r:
+			return results
+		case <-deadline:
+			t.Fatal("batch callback was not called")
+			return nil
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func (r batchResults) none(t *testing.T) {
+	t.Helper()
+
+	select {
+	case results := <-r:
+		t.Fatalf("batch callback called early with %d results", len(results))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestBatchPool(t *testing.T, workers, queueSize int) (*BatchWorkerPool, *gatedHandler) {
+	t.Helper()
+
+	bwp := NewBatchWorkerPool(workers, queueSize, 3, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := newGatedHandler()
+	bwp.RegisterHandler(JobTypeAnalyzeURL, handler.handle)
+	return bwp, handler
+}
+
+func batchOf(prefix string, n int) []Job {
+	jobs := make([]Job, n)
+	for i := range jobs {
+		jobs[i] = Job{ID: fmt.Sprintf("%s-%d", prefix, i), Key: fmt.Sprintf("%s-key-%d", prefix, i), Type: JobTypeAnalyzeURL}
+	}
+	return jobs
+}
+
+func resultIDs(results []JobResult) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.Job.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSubmitBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		// queueSize bounds the pool's queue; the pool has one busy worker.
+		queueSize int
+		jobs      []Job
+		// setup runs before the batch is submitted.
+		setup        func(t *testing.T, bwp *BatchWorkerPool)
+		wantIDs      []string
+		wantRejected int
+		want         BatchSummary
+	}{
+		{
+			name:      "empty batch",
+			queueSize: 10,
+			wantIDs:   []string{},
+			want:      BatchSummary{},
+		},
+		{
+			name:      "every job runs",
+			queueSize: 10,
+			jobs:      batchOf("job", 3),
+			wantIDs:   []string{"job-0", "job-1", "job-2"},
+			want:      BatchSummary{Total: 3, Succeeded: 3},
+		},
+		{
+			name:      "jobs coalesced with queued jobs",
+			queueSize: 10,
+			jobs:      batchOf("job", 2),
+			setup: func(t *testing.T, bwp *BatchWorkerPool) {
+				coalesced(t, bwp.WorkerPool, "earlier", "job-key-1", PriorityLow)
+			},
+			wantIDs: []string{"job-0", "earlier"},
+			want:    BatchSummary{Total: 2, Succeeded: 2},
+		},
+		{
+			name:         "rejected jobs reported as failed",
+			queueSize:    2,
+			jobs:         batchOf("job", 4),
+			wantIDs:      []string{"job-0", "job-1", "", ""},
+			wantRejected: 2,
+			want:         BatchSummary{Total: 4, Succeeded: 2, Failed: 2},
+		},
+		{
+			name:      "cancelled job not shared",
+			queueSize: 10,
+			jobs:      batchOf("job", 2),
+			setup: func(t *testing.T, bwp *BatchWorkerPool) {
+				coalesced(t, bwp.WorkerPool, "earlier", "job-key-1", PriorityLow)
+				if _, err := bwp.CancelJob("earlier"); err != nil {
+					t.Fatalf("CancelJob: %v", err)
+				}
+			},
+			wantIDs: []string{"job-0", "job-1"},
+			want:    BatchSummary{Total: 2, Succeeded: 2},
+		},
+		{
+			name:      "every job forwarded",
+			queueSize: 10,
+			jobs:      batchOf("job", 3),
+			setup: func(t *testing.T, bwp *BatchWorkerPool) {
+				bwp.ForwardJobs(&memoryQueue{}, JobTypeAnalyzeURL)
+			},
+			wantIDs: []string{"job-0", "job-1", "job-2"},
+			want:    BatchSummary{Total: 3, Forwarded: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bwp, handler := newTestBatchPool(t, 1, tt.queueSize)
+			ctx, cancel := context.WithCancel(context.Background())
+			bwp.Start(ctx)
+			defer bwp.Stop()
+			defer cancel()
+
+			bwp.RegisterHandler(JobTypeCrawlURL, handler.handle)
+			if err := bwp.AddJob(Job{ID: "blocker", Type: JobTypeCrawlURL}); err != nil {
+				t.Fatalf("AddJob: %v", err)
+			}
+			handler.settle()
+
+			if tt.setup != nil {
+				tt.setup(t, bwp)
+			}
+
+			results := make(batchResults, 1)
+			ids, rejected := bwp.SubmitBatch(BatchJob{ID: "batch", Jobs: tt.jobs, Callback: results.callback})
+
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("SubmitBatch returned IDs %v, want %v", ids, tt.wantIDs)
+			}
+			if len(rejected) != tt.wantRejected {
+				t.Errorf("SubmitBatch rejected %d jobs, want %d", len(rejected), tt.wantRejected)
+			}
+
+			if tt.want.Total > tt.want.Failed+tt.want.Forwarded {
+				results.none(t)
+			}
+			got := results.release(t, handler)
+
+			if summary := SummarizeBatch(got); summary != tt.want {
+				t.Errorf("batch finished with %+v, want %+v", summary, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmitBatchSharedJob(t *testing.T) {
+	bwp, handler := newTestBatchPool(t, 1, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	bwp.Start(ctx)
+	defer bwp.Stop()
+	defer cancel()
+
+	first := make(batchResults, 1)
+	second := make(batchResults, 1)
+	bwp.SubmitBatch(BatchJob{ID: "first", Jobs: batchOf("job", 2), Callback: first.callback})
+	handler.settle()
+	// job-0 is running, so the second batch gets a follow-up for it and
+	// shares the queued job-1.
+	ids, _ := bwp.SubmitBatch(BatchJob{ID: "second", Jobs: []Job{
+		{ID: "again-0", Key: "job-key-0", Type: JobTypeAnalyzeURL},
+		{ID: "again-1", Key: "job-key-1", Type: JobTypeAnalyzeURL},
+	}, Callback: second.callback})
+
+	if want := []string{"again-0", "job-1"}; fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("SubmitBatch returned IDs %v, want %v", ids, want)
+	}
+
+	finishJobs(handler, 3)
+
+	if got, want := resultIDs(first.wait(t)), []string{"job-0", "job-1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("first batch finished with %v, want %v", got, want)
+	}
+	if got, want := resultIDs(second.wait(t)), []string{"again-0", "job-1"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("second batch finished with %v, want %v", got, want)
+	}
+}
+
+func TestBatchDrain(t *testing.T) {
+	bwp, handler := newTestBatchPool(t, 1, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	bwp.Start(ctx)
+	defer bwp.Stop()
+	defer cancel()
+
+	results := make(batchResults, 1)
+	bwp.SubmitBatch(BatchJob{ID: "batch", Jobs: batchOf("job", 3), Callback: results.callback})
+	handler.settle()
+
+	// Jobs waiting for AddJobToBatch to fill a batch are handed back too.
+	if err := bwp.AddJobToBatch(Job{ID: "waiting", Type: JobTypeAnalyzeURL}); err != nil {
+		t.Fatalf("AddJobToBatch: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		handler.release()
+	}()
+	unfinished := bwp.Drain(time.Second)
+
+	var got []string
+	for _, job := range unfinished {
+		got = append(got, job.ID)
+	}
+	sort.Strings(got)
+	if want := []string{"job-1", "job-2", "waiting"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Drain() returned %v, want %v", got, want)
+	}
+
+	summary := SummarizeBatch(results.wait(t))
+	if want := (BatchSummary{Total: 3, Succeeded: 1, Interrupted: 2}); summary != want {
+		t.Errorf("batch finished with %+v, want %+v", summary, want)
+	}
+}
+
+func TestAddJobToBatch(t *testing.T) {
+	bwp, handler := newTestBatchPool(t, 4, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	bwp.Start(ctx)
+	defer bwp.Stop()
+	defer cancel()
+
+	results := make(batchResults, 2)
+	bwp.SetBatchCallback(results.callback)
+
+	for _, job := range batchOf("job", 4) {
+		if err := bwp.AddJobToBatch(job); err != nil {
+			t.Fatalf("AddJobToBatch(%s): %v", job.ID, err)
+		}
+	}
+
+	// The first three jobs filled a batch; the fourth waits for the next one.
+	if got := handler.settle(); got != 3 {
+		t.Errorf("%d jobs running after filling a batch, want 3", got)
+	}
+	handler.release()
+	if got := resultIDs(results.wait(t)); fmt.Sprint(got) != fmt.Sprint([]string{"job-0", "job-1", "job-2"}) {
+		t.Errorf("first batch finished with %v", got)
+	}
+
+	bwp.StartFlusher(ctx, 20*time.Millisecond)
+	if got := handler.settle(); got != 1 {
+		t.Errorf("%d jobs running after the flush, want 1", got)
+	}
+	handler.release()
+	if got := resultIDs(results.wait(t)); fmt.Sprint(got) != fmt.Sprint([]string{"job-3"}) {
+		t.Errorf("flushed batch finished with %v", got)
+	}
+}
+
+func TestSummarizeBatch(t *testing.T) {
+	results := []JobResult{
+		{},
+		{},
+		{Error: errors.New("boom")},
+		{Error: fmt.Errorf("job cancelled: %w", context.Canceled)},
+		{Error: ErrPoolDraining},
+		{Error: ErrJobForwarded},
+	}
+
+	want := BatchSummary{Total: 6, Succeeded: 2, Failed: 1, Cancelled: 1, Interrupted: 1, Forwarded: 1}
+	if got := SummarizeBatch(results); got != want {
+		t.Errorf("SummarizeBatch() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"log/slog"
+	"time"
+)
+
+type coalesceEntry struct {
+	current  string
+	job      Job
+	running  bool
+	followUp *Job
+}
+
+// AddCoalescedJob queues job unless an equivalent job, one with the same Key,
+// is already pending. While that job is queued its ID is returned instead,
+// and a high priority request moves it to the high priority queue; while it
+// runs, at most one follow-up run is scheduled to start after it and later
+// requests share the follow-up. Jobs forwarded to a durable queue are
+// coalesced with pending jobs in that queue instead, and jobs without a Key
+// are queued as with AddJob.
+func (wp *WorkerPool) AddCoalescedJob(job Job) (string, error) {
+	if job.Key == "" {
+		return job.ID, wp.AddJob(job)
+	}
+	if queue, forwarded := wp.forwardQueue(job.Type); forwarded {
+		return wp.forwardCoalescedJob(queue, job, 5*time.Second)
+	}
+
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	entry, exists := wp.coalesced[job.Key]
+	if exists && wp.registry.IsCancelled(entry.current) {
+		exists = false
+	}
+
+	switch {
+	case !exists:
+		if err := wp.AddJob(job); err != nil {
+			return "", err
+		}
+		wp.coalesced[job.Key] = &coalesceEntry{current: job.ID, job: job}
+		return job.ID, nil
+
+	case !entry.running:
+		if job.Priority == PriorityHigh {
+			wp.promoteQueued(entry)
+		}
+		wp.logger.Debug("Coalesced job into queued job",
+			slog.String("key", job.Key),
+			slog.String("job_id", entry.current))
+		return entry.current, nil
+
+	case entry.followUp != nil && !wp.registry.IsCancelled(entry.followUp.ID):
+		if job.Priority == PriorityHigh && entry.followUp.Priority != PriorityHigh {
+			entry.followUp.Priority = PriorityHigh
+			wp.registry.Promoted(entry.followUp.ID, PriorityHigh)
+		}
+		wp.logger.Debug("Coalesced job into scheduled follow-up",
+			slog.String("key", job.Key),
+			slog.String("job_id", entry.followUp.ID))
+		return entry.followUp.ID, nil
+
+	default:
+		if job.Priority == "" {
+			job.Priority = PriorityLow
+		}
+		entry.followUp = &job
+		wp.registry.Queued(job)
+		wp.logger.Debug("Scheduled follow-up for running job",
+			slog.String("key", job.Key),
+			slog.String("running_job_id", entry.current),
+			slog.String("job_id", job.ID))
+		return job.ID, nil
+	}
+}
+
+// promoteQueued queues a high priority copy of the entry's queued job. The
+// low priority copy stays in its queue and is dropped when a worker takes it,
+// whichever of the two comes out first.
+func (wp *WorkerPool) promoteQueued(entry *coalesceEntry) {
+	if entry.job.Priority == PriorityHigh || wp.highQueue == nil {
+		return
+	}
+	// A job waiting for a retry is not in a queue; its next attempt keeps
+	// the priority it was queued with.
+	if rec, ok := wp.registry.Get(entry.current); !ok || rec.State != JobStateQueued {
+		return
+	}
+
+	promoted := entry.job
+	promoted.Priority = PriorityHigh
+	if err := wp.requeue(promoted); err != nil {
+		wp.logger.Warn("Failed to promote coalesced job",
+			slog.String("key", promoted.Key),
+			slog.String("job_id", promoted.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	wp.superseded[promoted.ID] = true
+	entry.job = promoted
+	wp.registry.Promoted(promoted.ID, PriorityHigh)
+	wp.logger.Debug("Promoted coalesced job to high priority",
+		slog.String("key", promoted.Key),
+		slog.String("job_id", promoted.ID))
+}
+
+// claimCoalesced is called when a worker takes job. It reports false for the
+// low priority copy of a promoted job, which must not run; otherwise it marks
+// the key's job as running so later requests schedule a follow-up.
+func (wp *WorkerPool) claimCoalesced(job Job) bool {
+	if job.Key == "" {
+		return true
+	}
+
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	if wp.superseded[job.ID] && job.Priority != PriorityHigh {
+		delete(wp.superseded, job.ID)
+		return false
+	}
+	if entry, ok := wp.coalesced[job.Key]; ok && entry.current == job.ID {
+		entry.running = true
+	}
+	return true
+}
+
+// takeSuperseded reports whether job is the low priority copy of a promoted
+// job, forgetting the copy.
+func (wp *WorkerPool) takeSuperseded(job Job) bool {
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	if wp.superseded[job.ID] && job.Priority != PriorityHigh {
+		delete(wp.superseded, job.ID)
+		return true
+	}
+	return false
+}
+
+func (wp *WorkerPool) setCoalescedRunning(job Job, running bool) {
+	if job.Key == "" {
+		return
+	}
+
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	if entry, ok := wp.coalesced[job.Key]; ok && entry.current == job.ID {
+		entry.running = running
+	}
+}
+
+// releaseCoalesced is called once a job is done for good. A pending follow-up
+// takes its place in the queue, or is left for Drain to collect while the pool
+// is draining.
+func (wp *WorkerPool) releaseCoalesced(job Job) {
+	if job.Key == "" {
+		return
+	}
+
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	entry, ok := wp.coalesced[job.Key]
+	if !ok || entry.current != job.ID {
+		return
+	}
+
+	followUp := entry.followUp
+	if followUp == nil || wp.registry.IsCancelled(followUp.ID) {
+		delete(wp.coalesced, job.Key)
+		return
+	}
+	if wp.isDraining() {
+		entry.running = false
+		return
+	}
+
+	if err := wp.AddJob(*followUp); err != nil {
+		wp.logger.Error("Failed to queue follow-up job",
+			slog.String("key", job.Key),
+			slog.String("job_id", followUp.ID),
+			slog.String("error", err.Error()))
+		delete(wp.coalesced, job.Key)
+		return
+	}
+
+	wp.coalesced[job.Key] = &coalesceEntry{current: followUp.ID, job: *followUp}
+}
+
+// takeFollowUps removes and returns the follow-ups that never got to run.
+func (wp *WorkerPool) takeFollowUps() []Job {
+	wp.coalesceMu.Lock()
+	defer wp.coalesceMu.Unlock()
+
+	var jobs []Job
+	for key, entry := range wp.coalesced {
+		if entry.followUp != nil {
+			jobs = append(jobs, *entry.followUp)
+		}
+		delete(wp.coalesced, key)
+	}
+	return jobs
+}
@@ -0,0 +1,238 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler is a gatedHandler that also records the order in which
+// jobs start.
+type recordingHandler struct {
+	*gatedHandler
+	mu      sync.Mutex
+	started []string
+}
+
+func (h *recordingHandler) handle(ctx context.Context, job Job) (interface{}, error) {
+	h.mu.Lock()
+	h.started = append(h.started, job.ID)
+	h.mu.Unlock()
+	return h.gatedHandler.handle(ctx, job)
+}
+
+func (h *recordingHandler) startedJobs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.started...)
+}
+
+// finishAll releases jobs until none is left running.
+func (h *recordingHandler) finishAll() {
+	for {
+		h.release()
+		if h.settle() == 0 {
+			return
+		}
+	}
+}
+
+func coalesced(t *testing.T, wp *WorkerPool, id, key string, priority JobPriority) string {
+	t.Helper()
+
+	got, err := wp.AddCoalescedJob(Job{ID: id, Key: key, Type: JobTypeAnalyzeURL, Priority: priority})
+	if err != nil {
+		t.Fatalf("AddCoalescedJob(%s): %v", id, err)
+	}
+	return got
+}
+
+func TestAddCoalescedJob(t *testing.T) {
+	tests := []struct {
+		name string
+		// run makes the requests on a started single-worker pool whose
+		// worker is busy with the job "blocker", and returns the IDs they got.
+		run func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string
+		// blocked keeps the blocker running while run makes its requests.
+		blocked bool
+		want    []string
+		// started lists the jobs that ran after the blocker, in order.
+		started []string
+		// promoted lists the jobs that ran with high priority.
+		promoted []string
+	}{
+		{
+			name:    "queued job shared",
+			blocked: true,
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				return []string{
+					coalesced(t, wp, "a", "url-1", PriorityLow),
+					coalesced(t, wp, "b", "url-1", PriorityLow),
+					coalesced(t, wp, "c", "url-2", PriorityLow),
+				}
+			},
+			want:    []string{"a", "a", "c"},
+			started: []string{"a", "c"},
+		},
+		{
+			name:    "jobs without a key",
+			blocked: true,
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				return []string{
+					coalesced(t, wp, "a", "", PriorityLow),
+					coalesced(t, wp, "b", "", PriorityLow),
+				}
+			},
+			want:    []string{"a", "b"},
+			started: []string{"a", "b"},
+		},
+		{
+			name: "running job gets one follow-up",
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				ids := []string{coalesced(t, wp, "a", "url-1", PriorityLow)}
+				h.settle()
+				return append(ids,
+					coalesced(t, wp, "b", "url-1", PriorityLow),
+					coalesced(t, wp, "c", "url-1", PriorityLow))
+			},
+			want:    []string{"a", "b", "b"},
+			started: []string{"a", "b"},
+		},
+		{
+			name:    "cancelled queued job replaced",
+			blocked: true,
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				ids := []string{coalesced(t, wp, "a", "url-1", PriorityLow)}
+				if _, err := wp.CancelJob("a"); err != nil {
+					t.Fatalf("CancelJob: %v", err)
+				}
+				return append(ids, coalesced(t, wp, "b", "url-1", PriorityLow))
+			},
+			want:    []string{"a", "b"},
+			started: []string{"b"},
+		},
+		{
+			name: "cancelled follow-up replaced",
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				ids := []string{coalesced(t, wp, "a", "url-1", PriorityLow)}
+				h.settle()
+				ids = append(ids, coalesced(t, wp, "b", "url-1", PriorityLow))
+				if _, err := wp.CancelJob("b"); err != nil {
+					t.Fatalf("CancelJob: %v", err)
+				}
+				return append(ids, coalesced(t, wp, "c", "url-1", PriorityLow))
+			},
+			want:    []string{"a", "b", "c"},
+			started: []string{"a", "c"},
+		},
+		{
+			name:    "high priority request promotes queued job",
+			blocked: true,
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				addJobs(t, wp, "bulk", 2)
+				return []string{
+					coalesced(t, wp, "a", "url-1", PriorityLow),
+					coalesced(t, wp, "b", "url-1", PriorityHigh),
+				}
+			},
+			want:     []string{"a", "a"},
+			started:  []string{"a", "bulk-0", "bulk-1"},
+			promoted: []string{"a"},
+		},
+		{
+			name: "high priority request promotes follow-up",
+			run: func(t *testing.T, wp *WorkerPool, h *recordingHandler) []string {
+				ids := []string{coalesced(t, wp, "a", "url-1", PriorityLow)}
+				h.settle()
+				ids = append(ids, coalesced(t, wp, "b", "url-1", PriorityLow))
+				return append(ids, coalesced(t, wp, "c", "url-1", PriorityHigh))
+			},
+			want:     []string{"a", "b", "b"},
+			started:  []string{"a", "b"},
+			promoted: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pwp := NewPriorityWorkerPool(1, 100, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			wp := pwp.WorkerPool
+			h := &recordingHandler{gatedHandler: newGatedHandler()}
+			wp.RegisterHandler(JobTypeAnalyzeURL, h.handle)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wp.Start(ctx)
+			defer wp.Stop()
+			defer cancel()
+
+			if tt.blocked {
+				addJobs(t, wp, "blocker", 1)
+				h.settle()
+			}
+
+			got := tt.run(t, wp, h)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddCoalescedJob returned %v, want %v", got, tt.want)
+			}
+
+			h.finishAll()
+
+			started := h.startedJobs()
+			if tt.blocked {
+				started = started[1:]
+			}
+			if !reflect.DeepEqual(started, tt.started) {
+				t.Errorf("jobs started in order %v, want %v", started, tt.started)
+			}
+			for _, id := range tt.promoted {
+				if rec, _ := wp.GetJob(id); rec.Priority != PriorityHigh {
+					t.Errorf("job %s ran with %q priority, want %q", id, rec.Priority, PriorityHigh)
+				}
+			}
+			if wp.takeFollowUps() != nil {
+				t.Error("follow-ups left after every job finished")
+			}
+		})
+	}
+}
+
+func TestDrainReturnsCoalescedJobs(t *testing.T) {
+	pwp := NewPriorityWorkerPool(1, 100, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	wp := pwp.WorkerPool
+	handler := newGatedHandler()
+	wp.RegisterHandler(JobTypeAnalyzeURL, handler.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Start(ctx)
+	defer wp.Stop()
+	defer cancel()
+
+	coalesced(t, wp, "running", "url-1", PriorityLow)
+	handler.settle()
+	coalesced(t, wp, "follow-up", "url-1", PriorityLow)
+	coalesced(t, wp, "queued", "url-2", PriorityLow)
+	// The promoted job is handed back once, not once per queue.
+	coalesced(t, wp, "urgent", "url-2", PriorityHigh)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		handler.release()
+	}()
+	unfinished := wp.Drain(time.Second)
+
+	var got []string
+	for _, job := range unfinished {
+		got = append(got, job.ID)
+	}
+	sort.Strings(got)
+
+	want := []string{"follow-up", "queued"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() returned %v, want %v", got, want)
+	}
+}
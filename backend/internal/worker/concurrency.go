@@ -0,0 +1,231 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const MaxWorkers = 1024
+
+type typeLimiter struct {
+	mu       sync.Mutex
+	limits   map[JobType]int
+	running  map[JobType]int
+	deferred map[JobType][]Job
+}
+
+func newTypeLimiter() *typeLimiter {
+	return &typeLimiter{
+		limits:   make(map[JobType]int),
+		running:  make(map[JobType]int),
+		deferred: make(map[JobType][]Job),
+	}
+}
+
+// acquire takes a concurrency slot for the job's type. When the type is at its
+// cap the job is parked and false is returned; the worker that frees the next
+// slot picks it up in handoff.
+func (l *typeLimiter) acquire(job Job) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limits[job.Type]
+	if limit > 0 && l.running[job.Type] >= limit {
+		l.deferred[job.Type] = append(l.deferred[job.Type], job)
+		return false
+	}
+
+	l.running[job.Type]++
+	return true
+}
+
+// handoff is called when a job finishes. If another job of the same type is
+// parked and the cap still allows it, the slot is handed over directly.
+func (l *typeLimiter) handoff(jobType JobType) (Job, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limits[jobType]
+	pending := l.deferred[jobType]
+	if len(pending) > 0 && (limit <= 0 || l.running[jobType] <= limit) {
+		next := pending[0]
+		l.deferred[jobType] = pending[1:]
+		return next, true
+	}
+
+	l.running[jobType]--
+	return Job{}, false
+}
+
+// release frees a slot without handing it over, leaving parked jobs in place.
+func (l *typeLimiter) release(jobType JobType) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running[jobType]--
+}
+
+func (l *typeLimiter) takeDeferred() []Job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var jobs []Job
+	for jobType, pending := range l.deferred {
+		jobs = append(jobs, pending...)
+		delete(l.deferred, jobType)
+	}
+	return jobs
+}
+
+func (l *typeLimiter) park(job Job) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.deferred[job.Type] = append(l.deferred[job.Type], job)
+}
+
+func (l *typeLimiter) setLimit(jobType JobType, limit int) []Job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit <= 0 {
+		delete(l.limits, jobType)
+	} else {
+		l.limits[jobType] = limit
+	}
+
+	pending := l.deferred[jobType]
+	free := len(pending)
+	if limit > 0 {
+		free = limit - l.running[jobType]
+	}
+	if free <= 0 {
+		return nil
+	}
+	if free > len(pending) {
+		free = len(pending)
+	}
+
+	released := pending[:free:free]
+	l.deferred[jobType] = pending[free:]
+	return released
+}
+
+func (l *typeLimiter) snapshot() map[JobType]TypeConcurrency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[JobType]TypeConcurrency)
+	for jobType, limit := range l.limits {
+		stat := stats[jobType]
+		stat.Limit = limit
+		stats[jobType] = stat
+	}
+	for jobType, running := range l.running {
+		stat := stats[jobType]
+		stat.Running = running
+		stats[jobType] = stat
+	}
+	for jobType, pending := range l.deferred {
+		stat := stats[jobType]
+		stat.Waiting = len(pending)
+		stats[jobType] = stat
+	}
+	return stats
+}
+
+type TypeConcurrency struct {
+	Limit   int `json:"limit"`
+	Running int `json:"running"`
+	Waiting int `json:"waiting"`
+}
+
+func (wp *WorkerPool) SetConcurrencyLimit(jobType JobType, limit int) {
+	released := wp.limiter.setLimit(jobType, limit)
+
+	wp.logger.Info("Job type concurrency limit updated",
+		slog.String("job_type", string(jobType)),
+		slog.Int("limit", limit),
+		slog.Int("released", len(released)))
+
+	for _, job := range released {
+		if err := wp.requeue(job); err != nil {
+			wp.limiter.park(job)
+		}
+	}
+}
+
+func (wp *WorkerPool) Resize(workerCount int) error {
+	if workerCount < 1 || workerCount > MaxWorkers {
+		return fmt.Errorf("worker count must be between 1 and %d", MaxWorkers)
+	}
+
+	if wp.isDraining() {
+		return ErrPoolDraining
+	}
+
+	wp.sizeMu.Lock()
+	defer wp.sizeMu.Unlock()
+
+	if wp.runCtx == nil {
+		wp.workerCount = workerCount
+		return nil
+	}
+
+	previous := wp.workerCount
+	for i := previous; i < workerCount; i++ {
+		// Withdraw a retirement token a busy worker has not picked up yet, so
+		// that worker stays instead of a new one eating the stale token.
+		select {
+		case <-wp.retire:
+		default:
+			wp.spawnWorker()
+		}
+	}
+	for i := workerCount; i < previous; i++ {
+		wp.retire <- struct{}{}
+	}
+	wp.workerCount = workerCount
+
+	wp.logger.Info("Worker pool resized",
+		slog.Int("previous", previous),
+		slog.Int("workers", workerCount))
+
+	return nil
+}
+
+func (wp *WorkerPool) WorkerCount() int {
+	wp.sizeMu.Lock()
+	defer wp.sizeMu.Unlock()
+	return wp.workerCount
+}
+
+func (wp *WorkerPool) spawnWorker() {
+	wp.nextWorkerID++
+	wp.wg.Add(1)
+	go wp.worker(wp.runCtx, wp.nextWorkerID)
+}
+
+func (wp *WorkerPool) runJob(ctx context.Context, workerID int, job Job) {
+	if !wp.limiter.acquire(job) {
+		wp.logger.Debug("Job type at concurrency limit, deferring job",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)))
+		return
+	}
+
+	for {
+		wp.processJob(ctx, workerID, job)
+
+		if wp.isDraining() {
+			wp.limiter.release(job.Type)
+			return
+		}
+
+		next, ok := wp.limiter.handoff(job.Type)
+		if !ok {
+			return
+		}
+		job = next
+	}
+}
@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedHandler holds every job it runs until release is called, so a test can
+// see how many jobs the pool runs at once.
+type gatedHandler struct {
+	mu      sync.Mutex
+	running int
+	gate    chan struct{}
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{gate: make(chan struct{})}
+}
+
+func (h *gatedHandler) handle(ctx context.Context, job Job) (interface{}, error) {
+	h.mu.Lock()
+	h.running++
+	gate := h.gate
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		h.running--
+		h.mu.Unlock()
+	}()
+
+	select {
+	case <-gate:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (h *gatedHandler) release() {
+	h.mu.Lock()
+	close(h.gate)
+	h.gate = make(chan struct{})
+	h.mu.Unlock()
+}
+
+func (h *gatedHandler) runningJobs() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.running
+}
+
+// settle waits until the number of running jobs stops changing and returns it.
+func (h *gatedHandler) settle() int {
+	last := -1
+	for {
+		time.Sleep(20 * time.Millisecond)
+		running := h.runningJobs()
+		if running == last {
+			return running
+		}
+		last = running
+	}
+}
+
+func newTestPool(t *testing.T, workers int) (*WorkerPool, *gatedHandler) {
+	t.Helper()
+
+	wp := NewWorkerPool(workers, 100, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := newGatedHandler()
+	wp.RegisterHandler(JobTypeAnalyzeURL, handler.handle)
+	return wp, handler
+}
+
+func addJobs(t *testing.T, wp *WorkerPool, prefix string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		job := Job{ID: fmt.Sprintf("%s-%d", prefix, i), Type: JobTypeAnalyzeURL}
+		if err := wp.AddJob(job); err != nil {
+			t.Fatalf("AddJob(%s): %v", job.ID, err)
+		}
+	}
+}
+
+// finishJobs releases jobs until n of them have run.
+func finishJobs(handler *gatedHandler, n int) {
+	for finished := 0; finished < n; {
+		running := handler.runningJobs()
+		handler.release()
+		finished += running
+		handler.settle()
+	}
+}
+
+// liveWorkers counts the pool's workers by giving it more jobs than it can
+// run at once, then lets all of them finish.
+func liveWorkers(t *testing.T, wp *WorkerPool, handler *gatedHandler) int {
+	t.Helper()
+
+	// Idle workers pick up pending retirements first.
+	time.Sleep(20 * time.Millisecond)
+
+	const jobs = 16
+	addJobs(t, wp, "count", jobs)
+	workers := handler.settle()
+	finishJobs(handler, jobs)
+	return workers
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial int
+		// steps are applied in order. A negative size shrinks the pool to
+		// its absolute value while every worker is busy and more jobs are
+		// queued.
+		steps []int
+		// busy is the number of jobs running right after the steps, before
+		// the busy workers are released.
+		busy int
+		want int
+	}{
+		{"grow", 2, []int{5}, 0, 5},
+		{"shrink idle", 5, []int{2}, 0, 2},
+		{"shrink busy", 5, []int{-2}, 5, 2},
+		{"shrink busy then grow back", 4, []int{-2, 4}, 4, 4},
+		{"shrink busy then grow past", 4, []int{-1, 6}, 6, 6},
+		{"shrink busy then grow part way", 6, []int{-2, 4}, 6, 4},
+		{"shrink busy twice then grow", 6, []int{-4, -2, 5}, 6, 5},
+		{"grow then shrink", 2, []int{6, 3}, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp, handler := newTestPool(t, tt.initial)
+			ctx, cancel := context.WithCancel(context.Background())
+			wp.Start(ctx)
+			defer wp.Stop()
+			defer cancel()
+
+			queued := 0
+			for _, size := range tt.steps {
+				if size < 0 {
+					size = -size
+					if queued == 0 {
+						queued = wp.WorkerCount() + 8
+						addJobs(t, wp, "busy", queued)
+						if got := handler.settle(); got != wp.WorkerCount() {
+							t.Fatalf("%d jobs running before shrinking, want %d", got, wp.WorkerCount())
+						}
+					}
+				}
+				if err := wp.Resize(size); err != nil {
+					t.Fatalf("Resize(%d): %v", size, err)
+				}
+			}
+			if queued > 0 {
+				if got := handler.settle(); got != tt.busy {
+					t.Errorf("%d jobs running after resizing, want %d", got, tt.busy)
+				}
+				finishJobs(handler, queued)
+			}
+
+			if got := wp.WorkerCount(); got != tt.want {
+				t.Errorf("WorkerCount() = %d, want %d", got, tt.want)
+			}
+			if got := liveWorkers(t, wp, handler); got != tt.want {
+				t.Errorf("%d workers running, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResizeBeforeStart(t *testing.T) {
+	wp, handler := newTestPool(t, 2)
+	if err := wp.Resize(4); err != nil {
+		t.Fatalf("Resize(4): %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Start(ctx)
+	defer wp.Stop()
+	defer cancel()
+
+	if got := liveWorkers(t, wp, handler); got != 4 {
+		t.Errorf("%d workers running, want 4", got)
+	}
+}
+
+func TestResizeRejectsInvalidCounts(t *testing.T) {
+	wp, _ := newTestPool(t, 2)
+
+	for _, count := range []int{0, -1, MaxWorkers + 1} {
+		if err := wp.Resize(count); err == nil {
+			t.Errorf("Resize(%d) succeeded, want an error", count)
+		}
+	}
+	if got := wp.WorkerCount(); got != 2 {
+		t.Errorf("WorkerCount() = %d after invalid resizes, want 2", got)
+	}
+}
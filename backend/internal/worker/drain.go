@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var ErrPoolDraining = errors.New("worker pool is draining")
+
+// Drain stops the pool from taking on new work and gives running jobs until
+// timeout to finish; jobs still running then are interrupted. It returns every
+// job that did not finish - queued, waiting for a concurrency slot, waiting
+// for a retry, scheduled as a follow-up or interrupted - so the caller can
+// persist them. Call Stop afterwards to release the pool.
+func (wp *WorkerPool) Drain(timeout time.Duration) []Job {
+	wp.drainOnce.Do(func() {
+		wp.draining.Store(true)
+		close(wp.drain)
+	})
+
+	wp.logger.Info("Draining worker pool",
+		slog.Duration("timeout", timeout),
+		slog.Int("active_jobs", wp.activeCount()))
+
+	finished := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		wp.retryWg.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-finished:
+	case <-timer.C:
+		wp.interrupting.Store(true)
+
+		wp.activeMu.Lock()
+		interrupted := len(wp.active)
+		for _, cancel := range wp.active {
+			cancel()
+		}
+		wp.activeMu.Unlock()
+
+		wp.logger.Warn("Drain deadline reached, interrupting running jobs",
+			slog.Int("jobs", interrupted))
+		<-finished
+	}
+
+	wp.unfinishedMu.Lock()
+	unfinished := wp.unfinished
+	wp.unfinished = nil
+	wp.unfinishedMu.Unlock()
+
+	unfinished = append(unfinished, wp.limiter.takeDeferred()...)
+	unfinished = append(unfinished, wp.takeFollowUps()...)
+	for _, queue := range []chan Job{wp.highQueue, wp.jobQueue} {
+		if queue == nil {
+			continue
+		}
+		for len(queue) > 0 {
+			unfinished = append(unfinished, <-queue)
+		}
+	}
+
+	kept := unfinished[:0]
+	for _, job := range unfinished {
+		if !wp.registry.IsCancelled(job.ID) && !wp.takeSuperseded(job) {
+			kept = append(kept, job)
+		}
+	}
+
+	wp.listenerMu.RLock()
+	hooks := wp.drainHooks
+	wp.listenerMu.RUnlock()
+
+	for _, hook := range hooks {
+		kept = append(kept, hook(kept)...)
+	}
+
+	wp.logger.Info("Worker pool drained", slog.Int("unfinished_jobs", len(kept)))
+
+	return kept
+}
+
+func (wp *WorkerPool) isDraining() bool {
+	return wp.draining.Load()
+}
+
+func (wp *WorkerPool) keepUnfinished(job Job) {
+	wp.unfinishedMu.Lock()
+	defer wp.unfinishedMu.Unlock()
+	wp.unfinished = append(wp.unfinished, job)
+}
+
+func (wp *WorkerPool) activeCount() int {
+	wp.activeMu.Lock()
+	defer wp.activeMu.Unlock()
+	return len(wp.active)
+}
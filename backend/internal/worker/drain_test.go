@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		setup   func(t *testing.T, wp *WorkerPool)
+		// finish lets running jobs complete within the drain timeout.
+		finish bool
+		want   []string
+	}{
+		{
+			name:    "idle pool",
+			workers: 2,
+			setup:   func(t *testing.T, wp *WorkerPool) {},
+			want:    nil,
+		},
+		{
+			name:    "running jobs finish",
+			workers: 2,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 2)
+			},
+			finish: true,
+			want:   nil,
+		},
+		{
+			name:    "running job interrupted",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 1)
+			},
+			want: []string{"job-0"},
+		},
+		{
+			name:    "queued jobs handed back",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 3)
+			},
+			finish: true,
+			want:   []string{"job-1", "job-2"},
+		},
+		{
+			name:    "queued and interrupted jobs handed back",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 3)
+			},
+			want: []string{"job-0", "job-1", "job-2"},
+		},
+		{
+			name:    "cancelled jobs dropped",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 3)
+				if _, err := wp.CancelJob("job-2"); err != nil {
+					t.Fatalf("CancelJob: %v", err)
+				}
+			},
+			want: []string{"job-0", "job-1"},
+		},
+		{
+			name:    "job waiting for a retry handed back",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				attempted := make(chan struct{})
+				wp.RegisterHandler(JobTypeCrawlURL, func(ctx context.Context, job Job) (interface{}, error) {
+					close(attempted)
+					return nil, errors.New("connection reset")
+				})
+				wp.SetRetryPolicy(JobTypeCrawlURL, RetryPolicy{BaseDelay: time.Hour})
+				if err := wp.AddJob(Job{ID: "retry", Type: JobTypeCrawlURL, MaxRetry: 1}); err != nil {
+					t.Fatalf("AddJob: %v", err)
+				}
+				<-attempted
+			},
+			want: []string{"retry"},
+		},
+		{
+			name:    "drain hooks add jobs",
+			workers: 1,
+			setup: func(t *testing.T, wp *WorkerPool) {
+				addJobs(t, wp, "job", 2)
+				wp.AddDrainHook(func(unfinished []Job) []Job {
+					return []Job{{ID: "waiting", Type: JobTypeAnalyzeURL}}
+				})
+			},
+			finish: true,
+			want:   []string{"job-1", "waiting"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp, handler := newTestPool(t, tt.workers)
+			ctx, cancel := context.WithCancel(context.Background())
+			wp.Start(ctx)
+			defer wp.Stop()
+			defer cancel()
+
+			tt.setup(t, wp)
+			handler.settle()
+
+			if tt.finish {
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					handler.release()
+				}()
+			}
+
+			unfinished := wp.Drain(200 * time.Millisecond)
+
+			var got []string
+			for _, job := range unfinished {
+				got = append(got, job.ID)
+			}
+			sort.Strings(got)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Drain() returned %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Drain() returned %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDrainRejectsNewWork(t *testing.T) {
+	wp, _ := newTestPool(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Start(ctx)
+	defer wp.Stop()
+	defer cancel()
+
+	wp.Drain(time.Second)
+
+	if !wp.Draining() {
+		t.Error("Draining() = false after Drain")
+	}
+	if err := wp.AddJob(Job{ID: "late", Type: JobTypeAnalyzeURL}); !errors.Is(err, ErrPoolDraining) {
+		t.Errorf("AddJob after Drain returned %v, want ErrPoolDraining", err)
+	}
+	if err := wp.Resize(2); !errors.Is(err, ErrPoolDraining) {
+		t.Errorf("Resize after Drain returned %v, want ErrPoolDraining", err)
+	}
+}
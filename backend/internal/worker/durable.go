@@ -0,0 +1,415 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrJobCancelled is returned by Heartbeat when the leased job was
+	// cancelled in the queue.
+	ErrJobCancelled = errors.New("job cancelled in durable queue")
+)
+
+// DurableQueue is a job queue shared between processes. A leased job belongs
+// to its owner until the lease expires; owners keep it alive with Heartbeat.
+//
+// EnqueueCoalesced enqueues job unless a pending job with the same Key is
+// stored and returns the ID of the job that will run. Get and Cancel return
+// ErrJobNotFound and ErrJobFinished as the pool's own methods do.
+type DurableQueue interface {
+	Enqueue(ctx context.Context, job Job) error
+	EnqueueCoalesced(ctx context.Context, job Job) (string, error)
+	Get(ctx context.Context, jobID string) (JobRecord, error)
+	Cancel(ctx context.Context, jobID string) (JobRecord, error)
+	CancelKey(ctx context.Context, key string) ([]JobRecord, error)
+	Lease(ctx context.Context, owner string, jobTypes []JobType, lease time.Duration) (*Job, error)
+	Heartbeat(ctx context.Context, jobID, owner string, lease time.Duration) error
+	Complete(ctx context.Context, jobID, owner string) error
+	Fail(ctx context.Context, jobID, owner string, jobErr error) error
+	Release(ctx context.Context, jobID, owner string) error
+	ReleaseExpired(ctx context.Context) (int64, error)
+}
+
+// ForwardJobs sends jobs of the given types to queue instead of running them
+// in this pool, leaving them for a RemoteRunner in another process.
+func (wp *WorkerPool) ForwardJobs(queue DurableQueue, jobTypes ...JobType) {
+	wp.forwardMu.Lock()
+	defer wp.forwardMu.Unlock()
+
+	if wp.forwarded == nil {
+		wp.forwarded = make(map[JobType]DurableQueue)
+	}
+	for _, jobType := range jobTypes {
+		wp.forwarded[jobType] = queue
+	}
+}
+
+func (wp *WorkerPool) forwardQueue(jobType JobType) (DurableQueue, bool) {
+	wp.forwardMu.RLock()
+	defer wp.forwardMu.RUnlock()
+	queue, ok := wp.forwarded[jobType]
+	return queue, ok
+}
+
+// forwardQueues returns each queue jobs are forwarded to once.
+func (wp *WorkerPool) forwardQueues() []DurableQueue {
+	wp.forwardMu.RLock()
+	defer wp.forwardMu.RUnlock()
+
+	var queues []DurableQueue
+	seen := make(map[DurableQueue]bool)
+	for _, queue := range wp.forwarded {
+		if !seen[queue] {
+			seen[queue] = true
+			queues = append(queues, queue)
+		}
+	}
+	return queues
+}
+
+// GetForwardedJob looks up a job this pool forwarded to a durable queue.
+func (wp *WorkerPool) GetForwardedJob(ctx context.Context, id string) (JobRecord, error) {
+	for _, queue := range wp.forwardQueues() {
+		rec, err := queue.Get(ctx, id)
+		if !errors.Is(err, ErrJobNotFound) {
+			return rec, err
+		}
+	}
+	return JobRecord{}, ErrJobNotFound
+}
+
+// CancelForwardedJob cancels a job this pool forwarded to a durable queue.
+// A job a remote worker is running stops when the worker next renews its
+// lease.
+func (wp *WorkerPool) CancelForwardedJob(ctx context.Context, id string) (JobRecord, error) {
+	for _, queue := range wp.forwardQueues() {
+		rec, err := queue.Cancel(ctx, id)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err == nil {
+			wp.logger.Info("Forwarded job cancelled",
+				slog.String("job_id", id),
+				slog.String("job_type", string(rec.Type)))
+		}
+		return rec, err
+	}
+	return JobRecord{}, ErrJobNotFound
+}
+
+// CancelForwardedKey cancels the unfinished forwarded jobs with the given Key.
+func (wp *WorkerPool) CancelForwardedKey(ctx context.Context, key string) ([]JobRecord, error) {
+	var cancelled []JobRecord
+	for _, queue := range wp.forwardQueues() {
+		recs, err := queue.CancelKey(ctx, key)
+		if err != nil {
+			return cancelled, err
+		}
+		cancelled = append(cancelled, recs...)
+	}
+	return cancelled, nil
+}
+
+func (wp *WorkerPool) forwardCoalescedJob(queue DurableQueue, job Job, timeout time.Duration) (string, error) {
+	if job.Priority == "" {
+		job.Priority = PriorityLow
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	jobID, err := queue.EnqueueCoalesced(ctx, job)
+	if err != nil {
+		return "", fmt.Errorf("failed to forward job to durable queue: %w", err)
+	}
+
+	if jobID != job.ID {
+		wp.logger.Debug("Coalesced job into forwarded job",
+			slog.String("key", job.Key),
+			slog.String("job_id", jobID))
+		return jobID, nil
+	}
+
+	wp.jobLogger(job).Debug("Job forwarded to durable queue",
+		slog.String("job_id", job.ID),
+		slog.String("job_type", string(job.Type)),
+		slog.String("priority", string(job.Priority)))
+	return jobID, nil
+}
+
+func (wp *WorkerPool) forwardJob(queue DurableQueue, job Job, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := queue.Enqueue(ctx, job); err != nil {
+		return fmt.Errorf("failed to forward job to durable queue: %w", err)
+	}
+
+	wp.jobLogger(job).Debug("Job forwarded to durable queue",
+		slog.String("job_id", job.ID),
+		slog.String("job_type", string(job.Type)),
+		slog.String("priority", string(job.Priority)))
+	return nil
+}
+
+type RemoteRunnerConfig struct {
+	WorkerID      string
+	JobTypes      []JobType
+	LeaseDuration time.Duration
+	PollInterval  time.Duration
+}
+
+// RemoteRunner feeds a local pool with jobs leased from a DurableQueue and
+// reports their outcome back. Leases are renewed while a job is held, so a
+// runner that dies stops renewing and its jobs return to the queue.
+type RemoteRunner struct {
+	pool   *WorkerPool
+	queue  DurableQueue
+	config RemoteRunnerConfig
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+	done     chan struct{}
+}
+
+func NewRemoteRunner(pool *WorkerPool, queue DurableQueue, config RemoteRunnerConfig, logger *slog.Logger) *RemoteRunner {
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = time.Minute
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	runner := &RemoteRunner{
+		pool:     pool,
+		queue:    queue,
+		config:   config,
+		logger:   logger,
+		inFlight: make(map[string]struct{}),
+		done:     make(chan struct{}, 1),
+	}
+
+	pool.AddResultListener(runner.handleResult)
+
+	return runner
+}
+
+// Run leases jobs until ctx is cancelled. Jobs it still holds afterwards keep
+// their leases until Drain hands them back.
+func (r *RemoteRunner) Run(ctx context.Context) {
+	r.logger.Info("Remote runner started",
+		slog.String("worker_id", r.config.WorkerID),
+		slog.Duration("lease", r.config.LeaseDuration))
+
+	heartbeat := time.NewTicker(r.config.LeaseDuration / 3)
+	defer heartbeat.Stop()
+
+	reaper := time.NewTicker(r.config.LeaseDuration)
+	defer reaper.Stop()
+
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Remote runner stopped leasing", slog.String("worker_id", r.config.WorkerID))
+			return
+		case <-heartbeat.C:
+			r.renewLeases(ctx)
+		case <-reaper.C:
+			r.releaseExpired(ctx)
+		case <-r.done:
+			poll.Reset(0)
+		case <-poll.C:
+			if r.fill(ctx) {
+				poll.Reset(0)
+			} else {
+				poll.Reset(r.config.PollInterval)
+			}
+		}
+	}
+}
+
+// fill leases one job if the pool has room for it and reports whether it got
+// one, so the caller can keep leasing without waiting for the next poll.
+func (r *RemoteRunner) fill(ctx context.Context) bool {
+	if r.inFlightCount() >= r.pool.WorkerCount() {
+		return false
+	}
+
+	job, err := r.queue.Lease(ctx, r.config.WorkerID, r.config.JobTypes, r.config.LeaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to lease job", slog.String("error", err.Error()))
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	r.inFlight[job.ID] = struct{}{}
+	r.mu.Unlock()
+
+	if err := r.pool.AddJob(*job); err != nil {
+		r.logger.Warn("Failed to queue leased job, releasing it",
+			slog.String("job_id", job.ID),
+			slog.String("error", err.Error()))
+		r.forget(job.ID)
+		r.release(job.ID)
+		return false
+	}
+
+	r.logger.Debug("Leased job",
+		slog.String("job_id", job.ID),
+		slog.String("job_type", string(job.Type)))
+	return true
+}
+
+func (r *RemoteRunner) handleResult(result JobResult) {
+	if !r.forget(result.Job.ID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	if result.Error != nil {
+		err = r.queue.Fail(ctx, result.Job.ID, r.config.WorkerID, result.Error)
+	} else {
+		err = r.queue.Complete(ctx, result.Job.ID, r.config.WorkerID)
+	}
+	if err != nil {
+		r.logger.Error("Failed to report job result to durable queue",
+			slog.String("job_id", result.Job.ID),
+			slog.String("error", err.Error()))
+	}
+
+	select {
+	case r.done <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RemoteRunner) renewLeases(ctx context.Context) {
+	for _, jobID := range r.heldJobs() {
+		err := r.queue.Heartbeat(ctx, jobID, r.config.WorkerID, r.config.LeaseDuration)
+		if err == nil {
+			continue
+		}
+
+		if errors.Is(err, ErrJobCancelled) {
+			r.logger.Info("Job cancelled in durable queue, cancelling it", slog.String("job_id", jobID))
+			r.forget(jobID)
+			r.pool.CancelJob(jobID)
+			continue
+		}
+
+		if errors.Is(err, ErrLeaseLost) {
+			r.logger.Warn("Lease lost, cancelling job", slog.String("job_id", jobID))
+			r.forget(jobID)
+			r.pool.CancelJob(jobID)
+			continue
+		}
+
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to renew job lease",
+				slog.String("job_id", jobID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (r *RemoteRunner) releaseExpired(ctx context.Context) {
+	released, err := r.queue.ReleaseExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to release expired leases", slog.String("error", err.Error()))
+		}
+		return
+	}
+	if released > 0 {
+		r.logger.Info("Released expired leases", slog.Int64("jobs", released))
+	}
+}
+
+// Drain lets the pool finish the jobs this runner holds, renewing their leases
+// meanwhile, releases whatever is left back to the queue and returns it.
+func (r *RemoteRunner) Drain(timeout time.Duration) []Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(r.config.LeaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.renewLeases(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	unfinished := r.pool.Drain(timeout)
+	cancel()
+
+	held := r.heldJobs()
+	for _, jobID := range held {
+		r.forget(jobID)
+		r.release(jobID)
+	}
+
+	r.logger.Info("Remote runner drained",
+		slog.String("worker_id", r.config.WorkerID),
+		slog.Int("released", len(held)))
+
+	return unfinished
+}
+
+func (r *RemoteRunner) release(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.queue.Release(ctx, jobID, r.config.WorkerID); err != nil && !errors.Is(err, ErrLeaseLost) {
+		r.logger.Error("Failed to release job lease",
+			slog.String("job_id", jobID),
+			slog.String("error", err.Error()))
+	}
+}
+
+func (r *RemoteRunner) forget(jobID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, held := r.inFlight[jobID]
+	delete(r.inFlight, jobID)
+	return held
+}
+
+func (r *RemoteRunner) heldJobs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.inFlight))
+	for id := range r.inFlight {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (r *RemoteRunner) inFlightCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.inFlight)
+}
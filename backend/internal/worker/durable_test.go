@@ -0,0 +1,223 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryQueue is a DurableQueue that keeps its jobs in memory.
+type memoryQueue struct {
+	mu     sync.Mutex
+	jobs   []Job
+	states map[string]JobState
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.add(job)
+	return nil
+}
+
+func (q *memoryQueue) add(job Job) {
+	if q.states == nil {
+		q.states = make(map[string]JobState)
+	}
+	q.jobs = append(q.jobs, job)
+	q.states[job.ID] = JobStateQueued
+}
+
+func (q *memoryQueue) EnqueueCoalesced(ctx context.Context, job Job) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, queued := range q.jobs {
+		if queued.Key == job.Key && q.states[queued.ID] == JobStateQueued {
+			if job.Priority == PriorityHigh {
+				q.jobs[i].Priority = PriorityHigh
+			}
+			return queued.ID, nil
+		}
+	}
+	q.add(job)
+	return job.ID, nil
+}
+
+func (q *memoryQueue) Get(ctx context.Context, jobID string) (JobRecord, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.jobs {
+		if job.ID == jobID {
+			return q.record(job), nil
+		}
+	}
+	return JobRecord{}, ErrJobNotFound
+}
+
+func (q *memoryQueue) record(job Job) JobRecord {
+	return JobRecord{ID: job.ID, Type: job.Type, State: q.states[job.ID], Priority: job.Priority}
+}
+
+func (q *memoryQueue) Cancel(ctx context.Context, jobID string) (JobRecord, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.jobs {
+		if job.ID != jobID {
+			continue
+		}
+		if q.states[job.ID].IsFinal() {
+			return q.record(job), ErrJobFinished
+		}
+		q.states[job.ID] = JobStateCancelled
+		return q.record(job), nil
+	}
+	return JobRecord{}, ErrJobNotFound
+}
+
+func (q *memoryQueue) CancelKey(ctx context.Context, key string) ([]JobRecord, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var cancelled []JobRecord
+	for _, job := range q.jobs {
+		if job.Key == key && !q.states[job.ID].IsFinal() {
+			q.states[job.ID] = JobStateCancelled
+			cancelled = append(cancelled, q.record(job))
+		}
+	}
+	return cancelled, nil
+}
+
+func (q *memoryQueue) Lease(ctx context.Context, owner string, jobTypes []JobType, lease time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.jobs {
+		if q.states[job.ID] == JobStateQueued {
+			q.states[job.ID] = JobStateRunning
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *memoryQueue) Heartbeat(ctx context.Context, jobID, owner string, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch q.states[jobID] {
+	case JobStateRunning:
+		return nil
+	case JobStateCancelled:
+		return ErrJobCancelled
+	default:
+		return ErrLeaseLost
+	}
+}
+
+func (q *memoryQueue) Complete(ctx context.Context, jobID, owner string) error {
+	return q.finish(jobID, JobStateSucceeded)
+}
+
+func (q *memoryQueue) Fail(ctx context.Context, jobID, owner string, jobErr error) error {
+	return q.finish(jobID, JobStateFailed)
+}
+
+func (q *memoryQueue) Release(ctx context.Context, jobID, owner string) error {
+	return q.finish(jobID, JobStateQueued)
+}
+
+func (q *memoryQueue) finish(jobID string, state JobState) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.states[jobID] != JobStateRunning {
+		return ErrLeaseLost
+	}
+	q.states[jobID] = state
+	return nil
+}
+
+func (q *memoryQueue) ReleaseExpired(ctx context.Context) (int64, error) { return 0, nil }
+
+func TestForwardedJobs(t *testing.T) {
+	wp := NewWorkerPool(1, 10, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	queue := &memoryQueue{}
+	wp.ForwardJobs(queue, JobTypeAnalyzeURL)
+	ctx := context.Background()
+
+	if id := coalesced(t, wp, "a", "url-1", PriorityLow); id != "a" {
+		t.Errorf("first request got job %s, want a", id)
+	}
+	if id := coalesced(t, wp, "b", "url-1", PriorityHigh); id != "a" {
+		t.Errorf("second request got job %s, want the pending a", id)
+	}
+	if rec, err := wp.GetForwardedJob(ctx, "a"); err != nil || rec.Priority != PriorityHigh {
+		t.Errorf("GetForwardedJob(a) = %+v, %v, want a high priority job", rec, err)
+	}
+
+	if _, err := wp.CancelForwardedJob(ctx, "a"); err != nil {
+		t.Fatalf("CancelForwardedJob: %v", err)
+	}
+	if _, err := wp.CancelForwardedJob(ctx, "a"); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("cancelling a cancelled job returned %v, want ErrJobFinished", err)
+	}
+	if id := coalesced(t, wp, "c", "url-1", PriorityLow); id != "c" {
+		t.Errorf("request after the cancel got job %s, want c", id)
+	}
+
+	recs, err := wp.CancelForwardedKey(ctx, "url-1")
+	if err != nil || len(recs) != 1 || recs[0].ID != "c" {
+		t.Errorf("CancelForwardedKey = %+v, %v, want job c", recs, err)
+	}
+
+	if _, err := wp.GetForwardedJob(ctx, "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("GetForwardedJob(missing) returned %v, want ErrJobNotFound", err)
+	}
+	if _, ok := wp.GetJob("a"); ok {
+		t.Error("forwarded job is in the pool's registry")
+	}
+}
+
+func TestRemoteRunnerStopsCancelledJobs(t *testing.T) {
+	wp, handler := newTestPool(t, 1)
+	queue := &memoryQueue{}
+	queue.Enqueue(context.Background(), Job{ID: "a", Type: JobTypeAnalyzeURL})
+
+	runner := NewRemoteRunner(wp, queue, RemoteRunnerConfig{
+		WorkerID:      "test",
+		JobTypes:      []JobType{JobTypeAnalyzeURL},
+		LeaseDuration: 30 * time.Millisecond,
+		PollInterval:  10 * time.Millisecond,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Start(ctx)
+	defer wp.Stop()
+	defer cancel()
+	go runner.Run(ctx)
+
+	if handler.settle() != 1 {
+		t.Fatal("leased job is not running")
+	}
+	if _, err := queue.Cancel(ctx, "a"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if handler.settle() != 0 {
+		t.Fatal("cancelled job still running")
+	}
+	if rec, _ := wp.GetJob("a"); rec.State != JobStateCancelled {
+		t.Errorf("job finished as %q, want %q", rec.State, JobStateCancelled)
+	}
+	if rec, _ := queue.Get(ctx, "a"); rec.State != JobStateCancelled {
+		t.Errorf("job is %q in the queue, want %q", rec.State, JobStateCancelled)
+	}
+}
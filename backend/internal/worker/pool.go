@@ -2,9 +2,11 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,13 +18,39 @@ const (
 	JobTypeCleanup    JobType = "cleanup"
 )
 
+type JobPriority string
+
+const (
+	PriorityLow  JobPriority = "low"
+	PriorityHigh JobPriority = "high"
+)
+
 type Job struct {
 	ID        string
 	Type      JobType
+	Priority  JobPriority
 	Payload   interface{}
 	Retry     int
 	MaxRetry  int
 	CreatedAt time.Time
+	Timeout   time.Duration
+	History   []JobAttempt
+	// Key identifies equivalent jobs for AddCoalescedJob, e.g. "analyze_url:42".
+	Key string
+	// Metadata carries context across the queue, such as the trace context
+	// of the request that created the job.
+	Metadata map[string]string
+}
+
+// MetadataRequestID is the metadata key holding the ID of the API request
+// that created a job.
+const MetadataRequestID = "request_id"
+
+type JobAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
 }
 
 type JobResult struct {
@@ -33,24 +61,80 @@ type JobResult struct {
 
 type JobHandler func(ctx context.Context, job Job) (interface{}, error)
 
+type ResultListener func(result JobResult)
+
+// DrainHook is called at the end of Drain with the jobs the pool hands back.
+// Jobs it returns are handed back as well.
+type DrainHook func(unfinished []Job) []Job
+
+type DeadLetterHandler func(ctx context.Context, job Job, err error) error
+
 type WorkerPool struct {
-	workerCount int
-	jobQueue    chan Job
-	resultQueue chan JobResult
-	quit        chan struct{}
-	wg          sync.WaitGroup
-	handlers    map[JobType]JobHandler
-	logger      *slog.Logger
+	workerCount       int
+	sizeMu            sync.Mutex
+	nextWorkerID      int
+	runCtx            context.Context
+	retire            chan struct{}
+	limiter           *typeLimiter
+	jobQueue          chan Job
+	highQueue         chan Job
+	highWeight        atomic.Int64
+	dispatched        uint64
+	resultQueue       chan JobResult
+	quit              chan struct{}
+	drain             chan struct{}
+	drainOnce         sync.Once
+	draining          atomic.Bool
+	running           atomic.Bool
+	interrupting      atomic.Bool
+	unfinishedMu      sync.Mutex
+	unfinished        []Job
+	wg                sync.WaitGroup
+	handlers          map[JobType]JobHandler
+	registry          *JobRegistry
+	deadLetter        DeadLetterHandler
+	retryMu           sync.RWMutex
+	retries           map[JobType]RetryPolicy
+	retryWg           sync.WaitGroup
+	retriedMu         sync.Mutex
+	retried           map[JobType]int64
+	timeoutMu         sync.RWMutex
+	timeouts          map[JobType]TimeoutPolicy
+	coalesceMu        sync.Mutex
+	coalesced         map[string]*coalesceEntry
+	superseded        map[string]bool
+	forwardMu         sync.RWMutex
+	forwarded         map[JobType]DurableQueue
+	listenerMu        sync.RWMutex
+	progressListeners []ProgressListener
+	resultListeners   []ResultListener
+	drainHooks        []DrainHook
+	logger            *slog.Logger
+
+	activeMu sync.Mutex
+	active   map[string]context.CancelFunc
 }
 
+const defaultFinishedJobHistory = 1000
+
 func NewWorkerPool(workerCount int, queueSize int, logger *slog.Logger) *WorkerPool {
 	return &WorkerPool{
 		workerCount: workerCount,
 		jobQueue:    make(chan Job, queueSize),
 		resultQueue: make(chan JobResult, queueSize),
 		quit:        make(chan struct{}),
+		drain:       make(chan struct{}),
+		retire:      make(chan struct{}, MaxWorkers),
+		limiter:     newTypeLimiter(),
 		handlers:    make(map[JobType]JobHandler),
+		registry:    NewJobRegistry(defaultFinishedJobHistory),
+		retries:     make(map[JobType]RetryPolicy),
+		retried:     make(map[JobType]int64),
+		timeouts:    make(map[JobType]TimeoutPolicy),
+		coalesced:   make(map[string]*coalesceEntry),
+		superseded:  make(map[string]bool),
 		logger:      logger,
+		active:      make(map[string]context.CancelFunc),
 	}
 }
 
@@ -58,136 +142,436 @@ func (wp *WorkerPool) RegisterHandler(jobType JobType, handler JobHandler) {
 	wp.handlers[jobType] = handler
 }
 
+func (wp *WorkerPool) SetRetryPolicy(jobType JobType, policy RetryPolicy) {
+	wp.retryMu.Lock()
+	defer wp.retryMu.Unlock()
+	wp.retries[jobType] = policy
+}
+
+func (wp *WorkerPool) retryPolicy(jobType JobType) RetryPolicy {
+	wp.retryMu.RLock()
+	defer wp.retryMu.RUnlock()
+	if policy, ok := wp.retries[jobType]; ok {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
+
+func (wp *WorkerPool) SetDeadLetterHandler(handler DeadLetterHandler) {
+	wp.deadLetter = handler
+}
+
 func (wp *WorkerPool) Start(ctx context.Context) {
 	wp.logger.Info("Starting worker pool",
-		slog.Int("workers", wp.workerCount),
+		slog.Int("workers", wp.WorkerCount()),
 		slog.Int("queue_size", cap(wp.jobQueue)))
 
+	wp.sizeMu.Lock()
+	wp.runCtx = ctx
 	for i := 0; i < wp.workerCount; i++ {
-		wp.wg.Add(1)
-		go wp.worker(ctx, i)
+		wp.spawnWorker()
 	}
+	wp.sizeMu.Unlock()
 
 	go wp.processResults(ctx)
+	wp.running.Store(true)
 }
 
 func (wp *WorkerPool) Stop() {
 	wp.logger.Info("Stopping worker pool")
+	wp.running.Store(false)
 	close(wp.quit)
 	wp.wg.Wait()
+	wp.retryWg.Wait()
 	close(wp.jobQueue)
+	if wp.highQueue != nil {
+		close(wp.highQueue)
+	}
 	close(wp.resultQueue)
 	wp.logger.Info("Worker pool stopped")
 }
 
+// Running reports whether the pool has been started and neither stopped nor
+// cancelled through its context.
+func (wp *WorkerPool) Running() bool {
+	if !wp.running.Load() {
+		return false
+	}
+	wp.sizeMu.Lock()
+	defer wp.sizeMu.Unlock()
+	return wp.runCtx != nil && wp.runCtx.Err() == nil
+}
+
+// Draining reports whether Drain has been called.
+func (wp *WorkerPool) Draining() bool {
+	return wp.isDraining()
+}
+
 func (wp *WorkerPool) AddJob(job Job) error {
+	if job.Priority == "" {
+		job.Priority = PriorityLow
+	}
+	if queue, ok := wp.forwardQueue(job.Type); ok {
+		return wp.forwardJob(queue, job, 5*time.Second)
+	}
+	if wp.isDraining() {
+		return ErrPoolDraining
+	}
+	wp.registry.Queued(job)
+
 	select {
-	case wp.jobQueue <- job:
-		wp.logger.Debug("Job added to queue",
+	case wp.queueFor(job) <- job:
+		wp.jobLogger(job).Debug("Job added to queue",
 			slog.String("job_id", job.ID),
-			slog.String("job_type", string(job.Type)))
+			slog.String("job_type", string(job.Type)),
+			slog.String("priority", string(job.Priority)))
 		return nil
 	default:
-		return fmt.Errorf("job queue is full")
+		err := fmt.Errorf("%s priority job queue is full", job.Priority)
+		wp.registry.Finished(job, JobStateFailed, err)
+		return err
 	}
 }
 
 func (wp *WorkerPool) AddJobWithTimeout(job Job, timeout time.Duration) error {
+	if job.Priority == "" {
+		job.Priority = PriorityLow
+	}
+	if queue, ok := wp.forwardQueue(job.Type); ok {
+		return wp.forwardJob(queue, job, timeout)
+	}
+	if wp.isDraining() {
+		return ErrPoolDraining
+	}
+	wp.registry.Queued(job)
+
 	select {
-	case wp.jobQueue <- job:
-		wp.logger.Debug("Job added to queue",
+	case wp.queueFor(job) <- job:
+		wp.jobLogger(job).Debug("Job added to queue",
 			slog.String("job_id", job.ID),
-			slog.String("job_type", string(job.Type)))
+			slog.String("job_type", string(job.Type)),
+			slog.String("priority", string(job.Priority)))
 		return nil
 	case <-time.After(timeout):
-		return fmt.Errorf("timeout adding job to queue")
+		err := fmt.Errorf("timeout adding job to queue")
+		wp.registry.Finished(job, JobStateFailed, err)
+		return err
 	}
 }
 
+func (wp *WorkerPool) requeue(job Job) error {
+	if wp.isDraining() {
+		return ErrPoolDraining
+	}
+
+	select {
+	case wp.queueFor(job) <- job:
+		return nil
+	default:
+		return fmt.Errorf("%s priority job queue is full", job.Priority)
+	}
+}
+
+func (wp *WorkerPool) queueFor(job Job) chan Job {
+	if job.Priority == PriorityHigh && wp.highQueue != nil {
+		return wp.highQueue
+	}
+	return wp.jobQueue
+}
+
+func (wp *WorkerPool) nextJob(ctx context.Context) (Job, bool) {
+	if wp.isDraining() {
+		return Job{}, false
+	}
+
+	if wp.highQueue == nil {
+		select {
+		case job := <-wp.jobQueue:
+			return job, true
+		case <-wp.retire:
+			return Job{}, false
+		case <-wp.drain:
+			return Job{}, false
+		case <-wp.quit:
+			return Job{}, false
+		case <-ctx.Done():
+			return Job{}, false
+		}
+	}
+
+	// Every highWeight+1-th dispatch prefers the low priority queue so bulk
+	// work keeps progressing while interactive jobs are waiting.
+	first, second := wp.highQueue, wp.jobQueue
+	if atomic.AddUint64(&wp.dispatched, 1)%uint64(wp.highWeight.Load()+1) == 0 {
+		first, second = second, first
+	}
+
+	select {
+	case job := <-first:
+		return job, true
+	default:
+	}
+
+	select {
+	case job := <-second:
+		return job, true
+	default:
+	}
+
+	select {
+	case job := <-wp.highQueue:
+		return job, true
+	case job := <-wp.jobQueue:
+		return job, true
+	case <-wp.retire:
+		return Job{}, false
+	case <-wp.drain:
+		return Job{}, false
+	case <-wp.quit:
+		return Job{}, false
+	case <-ctx.Done():
+		return Job{}, false
+	}
+}
+
+func (wp *WorkerPool) CancelJob(id string) (JobRecord, error) {
+	rec, err := wp.registry.Cancel(id)
+	if err != nil {
+		return rec, err
+	}
+
+	wp.activeMu.Lock()
+	cancel, running := wp.active[id]
+	wp.activeMu.Unlock()
+
+	if running {
+		cancel()
+	}
+
+	wp.logger.Info("Job cancelled",
+		slog.String("job_id", id),
+		slog.String("job_type", string(rec.Type)),
+		slog.Bool("was_running", running))
+
+	return rec, nil
+}
+
+func (wp *WorkerPool) trackJob(id string, cancel context.CancelFunc) {
+	wp.activeMu.Lock()
+	wp.active[id] = cancel
+	wp.activeMu.Unlock()
+
+	if wp.registry.IsCancelled(id) {
+		cancel()
+	}
+}
+
+func (wp *WorkerPool) untrackJob(id string) {
+	wp.activeMu.Lock()
+	delete(wp.active, id)
+	wp.activeMu.Unlock()
+}
+
+func (wp *WorkerPool) GetJob(id string) (JobRecord, bool) {
+	return wp.registry.Get(id)
+}
+
+func (wp *WorkerPool) ListJobs(filter JobFilter) []JobRecord {
+	return wp.registry.List(filter)
+}
+
 func (wp *WorkerPool) worker(ctx context.Context, workerID int) {
 	defer wp.wg.Done()
 
 	wp.logger.Debug("Worker started", slog.Int("worker_id", workerID))
 
 	for {
-		select {
-		case job := <-wp.jobQueue:
-			wp.processJob(ctx, workerID, job)
-		case <-wp.quit:
+		job, ok := wp.nextJob(ctx)
+		if !ok {
 			wp.logger.Debug("Worker stopping", slog.Int("worker_id", workerID))
 			return
-		case <-ctx.Done():
-			wp.logger.Debug("Worker context cancelled", slog.Int("worker_id", workerID))
+		}
+		wp.runJob(ctx, workerID, job)
+
+		select {
+		case <-wp.retire:
+			wp.logger.Debug("Worker retired", slog.Int("worker_id", workerID))
 			return
+		default:
 		}
 	}
 }
 
+// jobLogger annotates the pool logger with the request that created job.
+func (wp *WorkerPool) jobLogger(job Job) *slog.Logger {
+	if requestID := job.Metadata[MetadataRequestID]; requestID != "" {
+		return wp.logger.With(slog.String("request_id", requestID))
+	}
+	return wp.logger
+}
+
 func (wp *WorkerPool) processJob(ctx context.Context, workerID int, job Job) {
 	start := time.Now()
+	logger := wp.jobLogger(job)
 
-	wp.logger.Debug("Processing job",
+	logger.Debug("Processing job",
 		slog.Int("worker_id", workerID),
 		slog.String("job_id", job.ID),
 		slog.String("job_type", string(job.Type)))
 
+	if !wp.claimCoalesced(job) {
+		logger.Debug("Skipping job moved to the high priority queue",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)))
+		return
+	}
+
+	if !wp.registry.Running(job) {
+		logger.Info("Skipping cancelled job",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)))
+		wp.sendResult(ctx, JobResult{
+			Job:   job,
+			Error: fmt.Errorf("job cancelled before start: %w", context.Canceled),
+		})
+		return
+	}
+
 	handler, exists := wp.handlers[job.Type]
 	if !exists {
-		wp.logger.Error("No handler registered for job type",
+		logger.Error("No handler registered for job type",
 			slog.String("job_type", string(job.Type)),
 			slog.String("job_id", job.ID))
 
-		wp.resultQueue <- JobResult{
+		err := fmt.Errorf("no handler registered for job type: %s", job.Type)
+		job.History = append(job.History, JobAttempt{
+			Attempt:    job.Retry + 1,
+			StartedAt:  start,
+			FinishedAt: time.Now(),
+			Error:      err.Error(),
+		})
+		wp.registry.Finished(job, JobStateFailed, err)
+		wp.deadLetterJob(job, err)
+		wp.sendResult(ctx, JobResult{
 			Job:   job,
-			Error: fmt.Errorf("no handler registered for job type: %s", job.Type),
-		}
+			Error: err,
+		})
 		return
 	}
 
-	jobCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	jobCtx, cancel := wp.jobContext(ctx, job)
 	defer cancel()
+	jobCtx = withProgress(jobCtx, wp, job)
+	jobCtx, span := startJobSpan(jobCtx, workerID, job)
+
+	wp.trackJob(job.ID, cancel)
+	defer wp.untrackJob(job.ID)
 
 	data, err := handler(jobCtx, job)
+	endJobSpan(span, err)
 
 	duration := time.Since(start)
 
-	if err != nil {
-		wp.logger.Error("Job processing failed",
+	if err != nil && wp.registry.IsCancelled(job.ID) {
+		logger.Info("Job stopped after cancellation",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)),
+			slog.Duration("duration", duration))
+
+		err = fmt.Errorf("job cancelled: %w", context.Canceled)
+	} else if err != nil && wp.interrupting.Load() && errors.Is(jobCtx.Err(), context.Canceled) {
+		logger.Info("Job interrupted by shutdown",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)),
+			slog.Duration("duration", duration))
+
+		wp.keepUnfinished(job)
+		return
+	} else if err != nil {
+		if TimedOut(jobCtx) && !errors.Is(err, ErrJobTimedOut) {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				err = context.Cause(jobCtx)
+			} else {
+				err = fmt.Errorf("%w (%v)", context.Cause(jobCtx), err)
+			}
+		}
+
+		job.History = append(job.History, JobAttempt{
+			Attempt:    job.Retry + 1,
+			StartedAt:  start,
+			FinishedAt: time.Now(),
+			Error:      err.Error(),
+		})
+
+		msg := "Job processing failed"
+		if errors.Is(err, ErrJobTimedOut) {
+			msg = "Job timed out"
+		}
+		logger.Error(msg,
 			slog.String("job_id", job.ID),
 			slog.String("job_type", string(job.Type)),
 			slog.String("error", err.Error()),
 			slog.Duration("duration", duration))
 
-		if job.Retry < job.MaxRetry {
+		policy := wp.retryPolicy(job.Type)
+		retryable := policy.ShouldRetry(err)
+		if retryable && job.Retry < job.MaxRetry {
 			job.Retry++
-			wp.logger.Info("Retrying job",
+			delay := policy.Delay(job.Retry, err)
+			logger.Info("Retrying job",
 				slog.String("job_id", job.ID),
 				slog.Int("retry", job.Retry),
-				slog.Int("max_retry", job.MaxRetry))
+				slog.Int("max_retry", job.MaxRetry),
+				slog.Duration("delay", delay))
 
-			time.Sleep(time.Duration(job.Retry) * time.Second)
-			wp.AddJob(job)
+			wp.registry.Retrying(job, err, time.Now().Add(delay))
+			wp.countRetry(job.Type)
+			wp.setCoalescedRunning(job, false)
+			wp.scheduleRetry(job, err, delay)
 			return
 		}
+
+		finalState := JobStateFailed
+		if errors.Is(err, ErrJobTimedOut) {
+			finalState = JobStateTimedOut
+		}
+
+		wp.registry.Finished(job, finalState, err)
+		if retryable {
+			wp.deadLetterJob(job, err)
+		} else {
+			logger.Info("Job failed with non-retryable error",
+				slog.String("job_id", job.ID),
+				slog.String("job_type", string(job.Type)),
+				slog.Int("attempt", job.Retry+1))
+		}
 	} else {
-		wp.logger.Debug("Job processed successfully",
+		wp.registry.Finished(job, JobStateSucceeded, nil)
+		logger.Debug("Job processed successfully",
 			slog.String("job_id", job.ID),
 			slog.String("job_type", string(job.Type)),
 			slog.Duration("duration", duration))
 	}
 
+	wp.sendResult(ctx, JobResult{Job: job, Error: err, Data: data})
+}
+
+func (wp *WorkerPool) sendResult(ctx context.Context, result JobResult) {
 	select {
-	case wp.resultQueue <- JobResult{Job: job, Error: err, Data: data}:
-	default:
-		wp.logger.Warn("Result queue full, dropping result",
-			slog.String("job_id", job.ID))
+	case wp.resultQueue <- result:
+	case <-ctx.Done():
+		wp.logger.Warn("Worker pool stopping, dropping result",
+			slog.String("job_id", result.Job.ID))
 	}
 }
 
 func (wp *WorkerPool) processResults(ctx context.Context) {
 	for {
 		select {
-		case result := <-wp.resultQueue:
+		case result, ok := <-wp.resultQueue:
+			if !ok {
+				return
+			}
 			wp.handleJobResult(result)
 		case <-ctx.Done():
 			return
@@ -196,34 +580,97 @@ func (wp *WorkerPool) processResults(ctx context.Context) {
 }
 
 func (wp *WorkerPool) handleJobResult(result JobResult) {
+	wp.releaseCoalesced(result.Job)
+	logger := wp.jobLogger(result.Job)
+
 	if result.Error != nil {
-		wp.logger.Error("Job failed",
+		logger.Error("Job failed",
 			slog.String("job_id", result.Job.ID),
 			slog.String("job_type", string(result.Job.Type)),
 			slog.String("error", result.Error.Error()))
 	} else {
-		wp.logger.Debug("Job completed successfully",
+		logger.Debug("Job completed successfully",
 			slog.String("job_id", result.Job.ID),
 			slog.String("job_type", string(result.Job.Type)))
 	}
+
+	wp.listenerMu.RLock()
+	listeners := wp.resultListeners
+	wp.listenerMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(result)
+	}
+}
+
+func (wp *WorkerPool) PruneJobs(olderThan time.Time, dryRun bool) int {
+	return wp.registry.PruneFinishedBefore(olderThan, dryRun)
+}
+
+func (wp *WorkerPool) AddResultListener(listener ResultListener) {
+	wp.listenerMu.Lock()
+	defer wp.listenerMu.Unlock()
+	wp.resultListeners = append(wp.resultListeners, listener)
+}
+
+func (wp *WorkerPool) AddDrainHook(hook DrainHook) {
+	wp.listenerMu.Lock()
+	defer wp.listenerMu.Unlock()
+	wp.drainHooks = append(wp.drainHooks, hook)
 }
 
 func (wp *WorkerPool) GetStats() PoolStats {
-	return PoolStats{
-		WorkerCount:    wp.workerCount,
-		JobsInQueue:    len(wp.jobQueue),
-		ResultsInQueue: len(wp.resultQueue),
-		QueueCapacity:  cap(wp.jobQueue),
+	wp.activeMu.Lock()
+	activeJobs := len(wp.active)
+	wp.activeMu.Unlock()
+
+	wp.retriedMu.Lock()
+	retried := make(map[JobType]int64, len(wp.retried))
+	for jobType, count := range wp.retried {
+		retried[jobType] = count
+	}
+	wp.retriedMu.Unlock()
+
+	stats := PoolStats{
+		WorkerCount:     wp.WorkerCount(),
+		ActiveJobs:      activeJobs,
+		Concurrency:     wp.limiter.snapshot(),
+		Retries:         retried,
+		JobsInQueue:     len(wp.jobQueue),
+		LowPriorityJobs: len(wp.jobQueue),
+		ResultsInQueue:  len(wp.resultQueue),
+		QueueCapacity:   cap(wp.jobQueue),
 	}
+	if wp.highQueue != nil {
+		stats.HighPriorityJobs = len(wp.highQueue)
+		stats.JobsInQueue += len(wp.highQueue)
+		stats.QueueCapacity += cap(wp.highQueue)
+	}
+	return stats
 }
 
 type PoolStats struct {
-	WorkerCount    int `json:"worker_count"`
-	JobsInQueue    int `json:"jobs_in_queue"`
-	ResultsInQueue int `json:"results_in_queue"`
-	QueueCapacity  int `json:"queue_capacity"`
+	WorkerCount      int `json:"worker_count"`
+	JobsInQueue      int `json:"jobs_in_queue"`
+	HighPriorityJobs int `json:"high_priority_jobs"`
+	LowPriorityJobs  int `json:"low_priority_jobs"`
+	ResultsInQueue   int `json:"results_in_queue"`
+	QueueCapacity    int `json:"queue_capacity"`
+	ActiveJobs       int `json:"active_jobs"`
+
+	Concurrency map[JobType]TypeConcurrency `json:"concurrency"`
+	// Retries counts scheduled retries per job type since the pool started.
+	Retries map[JobType]int64 `json:"retries"`
+}
+
+func (wp *WorkerPool) countRetry(jobType JobType) {
+	wp.retriedMu.Lock()
+	defer wp.retriedMu.Unlock()
+	wp.retried[jobType]++
 }
 
+const defaultHighPriorityWeight = 4
+
 type PriorityWorkerPool struct {
 	*WorkerPool
 	highPriorityQueue chan Job
@@ -231,99 +678,96 @@ type PriorityWorkerPool struct {
 }
 
 func NewPriorityWorkerPool(workerCount int, queueSize int, logger *slog.Logger) *PriorityWorkerPool {
-	wp := NewWorkerPool(workerCount, queueSize, logger)
+	wp := NewWorkerPool(workerCount, queueSize/2, logger)
+	wp.highQueue = make(chan Job, queueSize/2)
+	wp.highWeight.Store(defaultHighPriorityWeight)
 
 	return &PriorityWorkerPool{
 		WorkerPool:        wp,
-		highPriorityQueue: make(chan Job, queueSize/2),
-		lowPriorityQueue:  make(chan Job, queueSize/2),
+		highPriorityQueue: wp.highQueue,
+		lowPriorityQueue:  wp.jobQueue,
 	}
 }
 
-func (pwp *PriorityWorkerPool) AddHighPriorityJob(job Job) error {
-	select {
-	case pwp.highPriorityQueue <- job:
-		pwp.logger.Debug("High priority job added",
-			slog.String("job_id", job.ID),
-			slog.String("job_type", string(job.Type)))
-		return nil
-	default:
-		return fmt.Errorf("high priority queue is full")
+// SetHighPriorityWeight sets how many high priority jobs are dispatched for
+// every low priority one while both queues hold work. The weight is stored
+// atomically, so it can be changed while the pool is running.
+func (pwp *PriorityWorkerPool) SetHighPriorityWeight(weight int) {
+	if weight < 1 {
+		weight = 1
 	}
+	pwp.highWeight.Store(int64(weight))
 }
 
-func (pwp *PriorityWorkerPool) AddLowPriorityJob(job Job) error {
-	select {
-	case pwp.lowPriorityQueue <- job:
-		pwp.logger.Debug("Low priority job added",
-			slog.String("job_id", job.ID),
-			slog.String("job_type", string(job.Type)))
-		return nil
-	default:
-		return fmt.Errorf("low priority queue is full")
-	}
-}
-
-type BatchJob struct {
-	ID       string
-	Jobs     []Job
-	Callback func(results []JobResult)
+func (pwp *PriorityWorkerPool) AddHighPriorityJob(job Job) error {
+	job.Priority = PriorityHigh
+	return pwp.AddJob(job)
 }
 
-type BatchWorkerPool struct {
-	*WorkerPool
-	batchSize int
-	batch     []Job
-	mu        sync.Mutex
+func (pwp *PriorityWorkerPool) AddLowPriorityJob(job Job) error {
+	job.Priority = PriorityLow
+	return pwp.AddJob(job)
 }
 
-func NewBatchWorkerPool(workerCount int, queueSize int, batchSize int, logger *slog.Logger) *BatchWorkerPool {
-	wp := NewWorkerPool(workerCount, queueSize, logger)
-
-	return &BatchWorkerPool{
-		WorkerPool: wp,
-		batchSize:  batchSize,
-		batch:      make([]Job, 0, batchSize),
-	}
-}
+func (wp *WorkerPool) scheduleRetry(job Job, jobErr error, delay time.Duration) {
+	wp.retryWg.Add(1)
+	go func() {
+		defer wp.retryWg.Done()
 
-func (bwp *BatchWorkerPool) AddJobToBatch(job Job) error {
-	bwp.mu.Lock()
-	defer bwp.mu.Unlock()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
 
-	bwp.batch = append(bwp.batch, job)
+		select {
+		case <-timer.C:
+		case <-wp.drain:
+			wp.keepUnfinished(job)
+			return
+		case <-wp.quit:
+			wp.registry.Finished(job, JobStateFailed, fmt.Errorf("%v (worker pool stopped before retry)", jobErr))
+			wp.releaseCoalesced(job)
+			return
+		}
 
-	if len(bwp.batch) >= bwp.batchSize {
-		return bwp.processBatch()
-	}
+		if wp.registry.IsCancelled(job.ID) {
+			wp.releaseCoalesced(job)
+			return
+		}
 
-	return nil
+		if err := wp.requeue(job); errors.Is(err, ErrPoolDraining) {
+			wp.keepUnfinished(job)
+		} else if err != nil {
+			wp.logger.Error("Failed to requeue job",
+				slog.String("job_id", job.ID),
+				slog.String("error", err.Error()))
+			err = fmt.Errorf("%v (requeue failed: %w)", jobErr, err)
+			wp.registry.Finished(job, JobStateFailed, err)
+			wp.deadLetterJob(job, err)
+			wp.releaseCoalesced(job)
+		}
+	}()
 }
 
-func (bwp *BatchWorkerPool) processBatch() error {
-	if len(bwp.batch) == 0 {
-		return nil
+func (wp *WorkerPool) deadLetterJob(job Job, err error) {
+	if wp.deadLetter == nil {
+		wp.logger.Warn("Job exhausted retries, no dead-letter handler configured",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)))
+		return
 	}
 
-	batchJob := Job{
-		ID:        fmt.Sprintf("batch_%d", time.Now().Unix()),
-		Type:      "batch",
-		Payload:   bwp.batch,
-		CreatedAt: time.Now(),
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	err := bwp.AddJob(batchJob)
-	if err != nil {
-		return err
+	if dlErr := wp.deadLetter(ctx, job, err); dlErr != nil {
+		wp.logger.Error("Failed to dead-letter job",
+			slog.String("job_id", job.ID),
+			slog.String("job_type", string(job.Type)),
+			slog.String("error", dlErr.Error()))
+		return
 	}
 
-	bwp.batch = make([]Job, 0, bwp.batchSize)
-	return nil
+	wp.logger.Info("Job moved to dead-letter store",
+		slog.String("job_id", job.ID),
+		slog.String("job_type", string(job.Type)),
+		slog.Int("attempts", len(job.History)))
 }
-
-func (bwp *BatchWorkerPool) FlushBatch() error {
-	bwp.mu.Lock()
-	defer bwp.mu.Unlock()
-
-	return bwp.processBatch()
-}
\ No newline at end of file
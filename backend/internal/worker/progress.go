@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const progressInterval = 250 * time.Millisecond
+
+type Progress struct {
+	Phase     string    `json:"phase"`
+	Done      int       `json:"done,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Queued    int       `json:"queued,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProgressListener func(job Job, progress Progress)
+
+type progressKey struct{}
+
+type progressReporter struct {
+	wp   *WorkerPool
+	job  Job
+	mu   sync.Mutex
+	last Progress
+}
+
+// ReportProgress publishes the current phase of the running job to the pool's
+// progress listeners and counts as a heartbeat. Repeated updates within the
+// same phase are throttled, except for the one that completes the phase.
+func ReportProgress(ctx context.Context, progress Progress) {
+	Heartbeat(ctx)
+
+	reporter, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+	reporter.report(progress)
+}
+
+func (r *progressReporter) report(progress Progress) {
+	progress.UpdatedAt = time.Now()
+
+	r.mu.Lock()
+	samePhase := progress.Phase == r.last.Phase
+	finished := progress.Total > 0 && progress.Done >= progress.Total
+	if samePhase && !finished && progress.UpdatedAt.Sub(r.last.UpdatedAt) < progressInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.last = progress
+	r.mu.Unlock()
+
+	r.wp.registry.Progress(r.job.ID, progress)
+
+	r.wp.listenerMu.RLock()
+	listeners := r.wp.progressListeners
+	r.wp.listenerMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(r.job, progress)
+	}
+}
+
+func (wp *WorkerPool) AddProgressListener(listener ProgressListener) {
+	wp.listenerMu.Lock()
+	defer wp.listenerMu.Unlock()
+	wp.progressListeners = append(wp.progressListeners, listener)
+}
+
+func withProgress(ctx context.Context, wp *WorkerPool, job Job) context.Context {
+	return context.WithValue(ctx, progressKey{}, &progressReporter{wp: wp, job: job})
+}
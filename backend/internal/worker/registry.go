@@ -0,0 +1,271 @@
+package worker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateRetrying  JobState = "retrying"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+	JobStateCancelled JobState = "cancelled"
+	JobStateTimedOut  JobState = "timed_out"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
+)
+
+func (s JobState) IsFinal() bool {
+	return s == JobStateSucceeded || s == JobStateFailed || s == JobStateCancelled || s == JobStateTimedOut
+}
+
+type JobRecord struct {
+	ID         string      `json:"id"`
+	Type       JobType     `json:"type"`
+	State      JobState    `json:"state"`
+	Priority   JobPriority `json:"priority"`
+	Payload    interface{} `json:"payload,omitempty"`
+	Attempts   int         `json:"attempts"`
+	MaxRetry   int         `json:"max_retry"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	DurationMS int64       `json:"duration_ms"`
+
+	NextAttemptAt   *time.Time `json:"next_attempt_at,omitempty"`
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at,omitempty"`
+	Progress        *Progress  `json:"progress,omitempty"`
+}
+
+type JobFilter struct {
+	State JobState
+	Type  JobType
+	Limit int
+}
+
+type JobRegistry struct {
+	mu          sync.RWMutex
+	jobs        map[string]*JobRecord
+	maxFinished int
+}
+
+func NewJobRegistry(maxFinished int) *JobRegistry {
+	return &JobRegistry{
+		jobs:        make(map[string]*JobRecord),
+		maxFinished: maxFinished,
+	}
+}
+
+func (r *JobRegistry) Queued(job Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	createdAt := job.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	r.jobs[job.ID] = &JobRecord{
+		ID:        job.ID,
+		Type:      job.Type,
+		State:     JobStateQueued,
+		Priority:  job.Priority,
+		Payload:   job.Payload,
+		MaxRetry:  job.MaxRetry,
+		CreatedAt: createdAt,
+	}
+
+	r.prune()
+}
+
+func (r *JobRegistry) Running(job Job) bool {
+	return r.update(job.ID, func(rec *JobRecord) {
+		now := time.Now()
+		rec.State = JobStateRunning
+		rec.Attempts = job.Retry + 1
+		rec.StartedAt = &now
+		rec.FinishedAt = nil
+		rec.NextAttemptAt = nil
+		rec.LastHeartbeatAt = nil
+		rec.Progress = nil
+	})
+}
+
+func (r *JobRegistry) Promoted(id string, priority JobPriority) bool {
+	return r.update(id, func(rec *JobRecord) {
+		rec.Priority = priority
+	})
+}
+
+func (r *JobRegistry) Heartbeat(id string) bool {
+	return r.update(id, func(rec *JobRecord) {
+		now := time.Now()
+		rec.LastHeartbeatAt = &now
+	})
+}
+
+func (r *JobRegistry) Progress(id string, progress Progress) bool {
+	return r.update(id, func(rec *JobRecord) {
+		rec.Progress = &progress
+		rec.LastHeartbeatAt = &progress.UpdatedAt
+	})
+}
+
+func (r *JobRegistry) Retrying(job Job, err error, nextAttemptAt time.Time) bool {
+	return r.update(job.ID, func(rec *JobRecord) {
+		rec.State = JobStateRetrying
+		rec.Error = errorString(err)
+		rec.NextAttemptAt = &nextAttemptAt
+	})
+}
+
+func (r *JobRegistry) Finished(job Job, state JobState, err error) bool {
+	return r.update(job.ID, func(rec *JobRecord) {
+		now := time.Now()
+		rec.State = state
+		rec.Error = errorString(err)
+		rec.FinishedAt = &now
+		if rec.StartedAt != nil {
+			rec.DurationMS = now.Sub(*rec.StartedAt).Milliseconds()
+		}
+	})
+}
+
+func (r *JobRegistry) Cancel(id string) (JobRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.jobs[id]
+	if !ok {
+		return JobRecord{}, ErrJobNotFound
+	}
+	if rec.State.IsFinal() {
+		return *rec, ErrJobFinished
+	}
+
+	now := time.Now()
+	rec.State = JobStateCancelled
+	rec.Error = "cancelled"
+	rec.FinishedAt = &now
+	if rec.StartedAt != nil {
+		rec.DurationMS = now.Sub(*rec.StartedAt).Milliseconds()
+	}
+
+	return *rec, nil
+}
+
+func (r *JobRegistry) IsCancelled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.jobs[id]
+	return ok && rec.State == JobStateCancelled
+}
+
+func (r *JobRegistry) Get(id string) (JobRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.jobs[id]
+	if !ok {
+		return JobRecord{}, false
+	}
+	return *rec, true
+}
+
+func (r *JobRegistry) List(filter JobFilter) []JobRecord {
+	r.mu.RLock()
+	records := make([]JobRecord, 0, len(r.jobs))
+	for _, rec := range r.jobs {
+		if filter.State != "" && rec.State != filter.State {
+			continue
+		}
+		if filter.Type != "" && rec.Type != filter.Type {
+			continue
+		}
+		records = append(records, *rec)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records
+}
+
+func (r *JobRegistry) update(id string, fn func(*JobRecord)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.jobs[id]
+	if !ok || rec.State == JobStateCancelled {
+		return false
+	}
+
+	fn(rec)
+	return true
+}
+
+func (r *JobRegistry) prune() {
+	var finished []*JobRecord
+	for _, rec := range r.jobs {
+		if rec.State.IsFinal() {
+			finished = append(finished, rec)
+		}
+	}
+
+	if len(finished) <= r.maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+	for _, rec := range finished[:len(finished)-r.maxFinished] {
+		delete(r.jobs, rec.ID)
+	}
+}
+
+func (r *JobRegistry) PruneFinishedBefore(cutoff time.Time, dryRun bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pruned := 0
+	for id, rec := range r.jobs {
+		if !rec.State.IsFinal() {
+			continue
+		}
+		finishedAt := rec.CreatedAt
+		if rec.FinishedAt != nil {
+			finishedAt = *rec.FinishedAt
+		}
+		if finishedAt.Before(cutoff) {
+			pruned++
+			if !dryRun {
+				delete(r.jobs, id)
+			}
+		}
+	}
+	return pruned
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
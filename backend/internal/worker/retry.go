@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type RetryPolicy struct {
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Multiplier    float64
+	Jitter        float64
+	MaxRetryAfter time.Duration
+	Retryable     func(err error) bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	BaseDelay:     time.Second,
+	MaxDelay:      5 * time.Minute,
+	Multiplier:    2,
+	Jitter:        0.5,
+	MaxRetryAfter: 10 * time.Minute,
+}
+
+// Backoff returns the delay before the given retry attempt (1-based). Half of
+// the delay (with the default jitter) is randomised so retries from many jobs
+// failing together do not hit the target at the same moment.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	delay = delay*(1-jitter) + rand.Float64()*delay*jitter
+
+	return time.Duration(delay)
+}
+
+func (p RetryPolicy) ShouldRetry(err error) bool {
+	if err == nil || IsPermanent(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return true
+}
+
+// Delay picks the wait before the next attempt. A Retry-After hint from the
+// error wins over the computed backoff when it is longer.
+func (p RetryPolicy) Delay(attempt int, err error) time.Duration {
+	delay := p.Backoff(attempt)
+
+	if retryAfter, ok := RetryAfterHint(err); ok {
+		if p.MaxRetryAfter > 0 && retryAfter > p.MaxRetryAfter {
+			retryAfter = p.MaxRetryAfter
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+
+	return delay
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type retryAfterError interface {
+	RetryAfter() time.Duration
+}
+
+func RetryAfterHint(err error) (time.Duration, bool) {
+	var hinted retryAfterError
+	if errors.As(err, &hinted) && hinted.RetryAfter() > 0 {
+		return hinted.RetryAfter(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type hintedError struct {
+	retryAfter time.Duration
+}
+
+func (e hintedError) Error() string             { return "rate limited" }
+func (e hintedError) RetryAfter() time.Duration { return e.retryAfter }
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	errTransient := errors.New("connection reset")
+	errRejected := errors.New("rejected")
+	onlyTransient := RetryPolicy{Retryable: func(err error) bool { return !errors.Is(err, errRejected) }}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		err    error
+		want   bool
+	}{
+		{"nil error", DefaultRetryPolicy, nil, false},
+		{"plain error", DefaultRetryPolicy, errTransient, true},
+		{"permanent", DefaultRetryPolicy, Permanent(errTransient), false},
+		{"wrapped permanent", DefaultRetryPolicy, fmt.Errorf("crawl: %w", Permanent(errTransient)), false},
+		{"cancelled", DefaultRetryPolicy, fmt.Errorf("analysis cancelled: %w", context.Canceled), false},
+		{"timed out", DefaultRetryPolicy, ErrJobTimedOut, true},
+		{"deadline exceeded", DefaultRetryPolicy, context.DeadlineExceeded, true},
+		{"classifier accepts", onlyTransient, errTransient, true},
+		{"classifier rejects", onlyTransient, errRejected, false},
+		{"permanent before classifier", onlyTransient, Permanent(errTransient), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.ShouldRetry(tt.err); got != tt.want {
+				t.Errorf("ShouldRetry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			if got := policy.Backoff(tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 4 * time.Second, Multiplier: 1, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(1); got < 2*time.Second || got > 4*time.Second {
+			t.Fatalf("Backoff(1) = %v, want between 2s and 4s", got)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, Multiplier: 2, MaxRetryAfter: time.Minute}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{"no hint", 2, errors.New("reset"), 2 * time.Second},
+		{"shorter hint", 2, hintedError{retryAfter: time.Second}, 2 * time.Second},
+		{"longer hint", 2, hintedError{retryAfter: 30 * time.Second}, 30 * time.Second},
+		{"wrapped hint", 1, fmt.Errorf("crawl: %w", hintedError{retryAfter: 5 * time.Second}), 5 * time.Second},
+		{"hint above cap", 1, hintedError{retryAfter: time.Hour}, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Delay(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("Delay(%d, %v) = %v, want %v", tt.attempt, tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const DefaultJobTimeout = 30 * time.Second
+
+var ErrJobTimedOut = errors.New("job timed out")
+
+type TimeoutPolicy struct {
+	Timeout          time.Duration
+	HeartbeatTimeout time.Duration
+}
+
+type heartbeatKey struct{}
+
+// Heartbeat tells the pool that a long-running job is still making progress.
+// Jobs whose type has a HeartbeatTimeout are stopped once they go quiet for
+// longer than that, independently of their overall deadline.
+func Heartbeat(ctx context.Context) {
+	beats, ok := ctx.Value(heartbeatKey{}).(chan struct{})
+	if !ok {
+		return
+	}
+	select {
+	case beats <- struct{}{}:
+	default:
+	}
+}
+
+func TimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrJobTimedOut)
+}
+
+func (wp *WorkerPool) SetTimeoutPolicy(jobType JobType, policy TimeoutPolicy) {
+	wp.timeoutMu.Lock()
+	defer wp.timeoutMu.Unlock()
+	wp.timeouts[jobType] = policy
+}
+
+func (wp *WorkerPool) timeoutPolicy(job Job) TimeoutPolicy {
+	wp.timeoutMu.RLock()
+	policy, ok := wp.timeouts[job.Type]
+	wp.timeoutMu.RUnlock()
+
+	if !ok || policy.Timeout <= 0 {
+		policy.Timeout = DefaultJobTimeout
+	}
+	if job.Timeout > 0 {
+		policy.Timeout = job.Timeout
+	}
+	return policy
+}
+
+func (wp *WorkerPool) jobContext(parent context.Context, job Job) (context.Context, context.CancelFunc) {
+	policy := wp.timeoutPolicy(job)
+
+	deadlineCtx, cancelDeadline := context.WithTimeoutCause(parent, policy.Timeout,
+		fmt.Errorf("%w after %s", ErrJobTimedOut, policy.Timeout))
+	ctx, cancelCause := context.WithCancelCause(deadlineCtx)
+
+	cancel := func() {
+		cancelCause(context.Canceled)
+		cancelDeadline()
+	}
+
+	if policy.HeartbeatTimeout <= 0 {
+		return ctx, cancel
+	}
+
+	beats := make(chan struct{}, 1)
+	ctx = context.WithValue(ctx, heartbeatKey{}, beats)
+
+	go func() {
+		timer := time.NewTimer(policy.HeartbeatTimeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-beats:
+				wp.registry.Heartbeat(job.ID)
+				timer.Reset(policy.HeartbeatTimeout)
+			case <-timer.C:
+				cancelCause(fmt.Errorf("%w: no heartbeat for %s", ErrJobTimedOut, policy.HeartbeatTimeout))
+				return
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("searcher-app/internal/worker")
+
+// InjectTraceContext stores the trace context of ctx in the job metadata, so
+// the job's run continues the trace even in another process.
+func InjectTraceContext(ctx context.Context, job *Job) {
+	if job.Metadata == nil {
+		job.Metadata = make(map[string]string)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(job.Metadata))
+}
+
+func startJobSpan(ctx context.Context, workerID int, job Job) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(job.Metadata))
+
+	attrs := []attribute.KeyValue{
+		attribute.String("job.id", job.ID),
+		attribute.String("job.type", string(job.Type)),
+		attribute.String("job.priority", string(job.Priority)),
+		attribute.Int("job.attempt", job.Retry+1),
+		attribute.Int("worker.id", workerID),
+	}
+	if requestID := job.Metadata[MetadataRequestID]; requestID != "" {
+		attrs = append(attrs, attribute.String("request.id", requestID))
+	}
+	if !job.CreatedAt.IsZero() {
+		attrs = append(attrs, attribute.Int64("job.age_ms", time.Since(job.CreatedAt).Milliseconds()))
+	}
+
+	return tracer.Start(ctx, "job "+string(job.Type),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...))
+}
+
+func endJobSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS links (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    url_id INT NOT NULL,
+    source_host VARCHAR(255) NOT NULL,
+    target_url VARCHAR(2048) NOT NULL,
+    anchor_text VARCHAR(500),
+    rel VARCHAR(255),
+    is_internal BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_url_id (url_id),
+    INDEX idx_source_host_internal (source_host, is_internal)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE urls
+    ADD COLUMN content_hash CHAR(64) NULL AFTER has_login_form,
+    ADD COLUMN content_simhash CHAR(16) NULL AFTER content_hash,
+    ADD COLUMN content_changed BOOLEAN NULL AFTER content_simhash,
+    ADD INDEX idx_content_hash (content_hash);
@@ -0,0 +1,3 @@
+ALTER TABLE urls
+    ADD COLUMN etag VARCHAR(255) NULL AFTER content_changed,
+    ADD COLUMN last_modified VARCHAR(64) NULL AFTER etag;
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+    ADD COLUMN charset VARCHAR(50) NULL AFTER html_version;
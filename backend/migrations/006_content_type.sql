@@ -0,0 +1,4 @@
+ALTER TABLE urls
+    ADD COLUMN content_type VARCHAR(255) NULL AFTER charset,
+    ADD COLUMN content_length BIGINT NULL AFTER content_type,
+    MODIFY COLUMN status ENUM('queued', 'processing', 'completed', 'error', 'not_html') DEFAULT 'queued';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS dead_letter_jobs (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    job_id VARCHAR(255) NOT NULL,
+    job_type VARCHAR(50) NOT NULL,
+    priority VARCHAR(20) NOT NULL DEFAULT 'low',
+    payload JSON,
+    last_error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    max_retry INT NOT NULL DEFAULT 0,
+    attempt_history JSON,
+    job_created_at TIMESTAMP NULL,
+    failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    replay_count INT NOT NULL DEFAULT 0,
+    last_replayed_at TIMESTAMP NULL,
+
+    INDEX idx_job_type (job_type),
+    INDEX idx_failed_at (failed_at),
+    INDEX idx_job_id (job_id)
+);
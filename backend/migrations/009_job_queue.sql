@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS job_queue (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id VARCHAR(255) NOT NULL UNIQUE,
+    job_type VARCHAR(50) NOT NULL,
+    priority VARCHAR(20) NOT NULL DEFAULT 'low',
+    payload JSON,
+    status ENUM('pending', 'leased', 'succeeded', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_retry INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    lease_owner VARCHAR(255),
+    lease_expires_at TIMESTAMP(3) NULL,
+    heartbeat_at TIMESTAMP(3) NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+
+    INDEX idx_status_available (status, job_type, available_at),
+    INDEX idx_lease_expires (status, lease_expires_at)
+);
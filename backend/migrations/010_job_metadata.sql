@@ -0,0 +1,2 @@
+ALTER TABLE job_queue
+    ADD COLUMN metadata JSON NULL AFTER payload;
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+    MODIFY COLUMN status ENUM('queued', 'processing', 'completed', 'error', 'not_html', 'cancelled', 'purged') DEFAULT 'queued';
@@ -0,0 +1,4 @@
+ALTER TABLE job_queue
+    MODIFY COLUMN status ENUM('pending', 'leased', 'succeeded', 'failed', 'cancelled') NOT NULL DEFAULT 'pending',
+    ADD COLUMN job_key VARCHAR(255) NULL AFTER job_type,
+    ADD INDEX idx_job_key (job_key, status);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS job_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    event JSON NOT NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+
+    INDEX idx_created_at (created_at)
+);
@@ -0,0 +1,40 @@
+import React from 'react';
+import { useTranslation } from 'react-i18next';
+import type { JobProgress } from '../../types/types';
+
+interface JobProgressNoteProps {
+  progress: JobProgress;
+}
+
+export const JobProgressNote: React.FC<JobProgressNoteProps> = ({
+  progress,
+}) => {
+  const { t } = useTranslation();
+
+  const label = t(`progress.${progress.phase}`, {
+    done: progress.done ?? 0,
+    total: progress.total ?? 0,
+    queued: progress.queued ?? 0,
+    defaultValue: progress.message || progress.phase,
+  });
+  const percent =
+    progress.total && progress.total > 0
+      ? Math.min(100, Math.round(((progress.done ?? 0) / progress.total) * 100))
+      : null;
+
+  return (
+    <div className='mt-1 max-w-xs'>
+      <div className='text-xs text-blue-600 truncate'>{label}</div>
+      {percent !== null && (
+        <div className='mt-1 h-1 w-full rounded bg-blue-100'>
+          <div
+            className='h-1 rounded bg-blue-500'
+            style={{ width: `${percent}%` }}
+          />
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default JobProgressNote;
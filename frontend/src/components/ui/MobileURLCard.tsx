@@ -2,6 +2,7 @@ import React from 'react';
 import { useTranslation } from 'react-i18next';
 import { Link } from 'react-router-dom';
 import type { URLAnalysis } from '../../types/types';
+import JobProgressNote from './JobProgressNote';
 import StatusBadge from './StatusBadge';
 
 interface MobileURLCardProps {
@@ -39,7 +40,12 @@ const MobileURLCard: React.FC<MobileURLCardProps> = ({
               </div>
             </div>
           </div>
-          <StatusBadge status={url.status} />
+          <div className='text-right'>
+            <StatusBadge status={url.status} />
+            {url.status === 'processing' && url.progress && (
+              <JobProgressNote progress={url.progress} />
+            )}
+          </div>
         </div>
         
         <div className='grid grid-cols-2 gap-4 text-sm'>
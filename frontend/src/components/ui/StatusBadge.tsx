@@ -1,11 +1,21 @@
 import {
+  ArchiveBoxIcon,
   ArrowPathIcon,
   CheckCircleIcon,
   ClockIcon,
+  DocumentIcon,
+  NoSymbolIcon,
   XCircleIcon,
 } from '@heroicons/react/24/outline';
 import React from 'react';
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
 
 interface StatusBadgeProps {
   status: URLStatus;
@@ -39,6 +49,21 @@ const StatusBadge: React.FC<StatusBadgeProps> = ({
       icon: XCircleIcon,
       text: 'Error',
     },
+    not_html: {
+      color: 'bg-yellow-100 text-yellow-800',
+      icon: DocumentIcon,
+      text: 'Not HTML',
+    },
+    cancelled: {
+      color: 'bg-gray-100 text-gray-600',
+      icon: NoSymbolIcon,
+      text: 'Cancelled',
+    },
+    purged: {
+      color: 'bg-gray-100 text-gray-500',
+      icon: ArchiveBoxIcon,
+      text: 'Purged',
+    },
   };
 
   const config = statusConfig[status];
@@ -1,6 +1,7 @@
 import React from 'react';
 import { useTranslation } from 'react-i18next';
 import { Link } from 'react-router-dom';
+import JobProgressNote from './JobProgressNote';
 import StatusBadge from './StatusBadge';
 
 interface URLTableRowProps {
@@ -9,12 +10,28 @@ interface URLTableRowProps {
   onToggleSelect: (id: number) => void;
   onDelete: (id: number) => void;
 }
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
+export interface JobProgress {
+  phase: string;
+  done?: number;
+  total?: number;
+  queued?: number;
+  message?: string;
+  updated_at: string;
+}
 export interface URLAnalysis {
   id: number;
   url: string;
   title?: string;
   html_version?: string;
+  content_type?: string;
   h1_count: number;
   h2_count: number;
   h3_count: number;
@@ -27,6 +44,7 @@ export interface URLAnalysis {
   has_login_form: boolean;
   status: URLStatus;
   error_message?: string;
+  progress?: JobProgress;
   created_at: string;
   updated_at: string;
 }
@@ -55,7 +73,9 @@ export const URLTableRow: React.FC<URLTableRowProps> = React.memo(
         </td>
         <td className='px-6 py-4 whitespace-nowrap'>
           <div className='text-sm text-gray-900'>
-            {url.html_version || 'N/A'}
+            {url.status === 'not_html'
+              ? url.content_type || 'N/A'
+              : url.html_version || 'N/A'}
           </div>
         </td>
         <td className='px-6 py-4 whitespace-nowrap'>
@@ -107,6 +127,9 @@ export const URLTableRow: React.FC<URLTableRowProps> = React.memo(
         </td>
         <td className='px-6 py-4 whitespace-nowrap'>
           <StatusBadge status={url.status} />
+          {url.status === 'processing' && url.progress && (
+            <JobProgressNote progress={url.progress} />
+          )}
           {url.error_message && (
             <div className='text-xs text-red-600 mt-1 truncate max-w-xs'>
               {url.error_message}
@@ -1,8 +1,21 @@
 import { useCallback, useEffect, useState } from 'react';
+import toast from 'react-hot-toast';
+import { useTranslation } from 'react-i18next';
 import APIService from '../services/api';
-import { useWebSocket } from './useWebSocket';
+import {
+  BatchFinishedUpdate,
+  JobProgressUpdate,
+  useWebSocket,
+} from './useWebSocket';
 
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
 
 interface UseURLsOptions {
   page?: number;
@@ -30,6 +43,7 @@ export interface URLAnalysis {
   has_login_form: boolean;
   status: URLStatus;
   error_message?: string;
+  progress?: JobProgressUpdate['data']['progress'];
   created_at: string;
   updated_at: string;
 }
@@ -43,6 +57,7 @@ export interface PaginatedResponse<T> {
 }
 
 export const useURLs = (options: UseURLsOptions = {}) => {
+  const { t } = useTranslation();
   const [urls, setUrls] = useState<URLAnalysis[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
@@ -69,11 +84,16 @@ export const useURLs = (options: UseURLsOptions = {}) => {
         if (update.status === 'deleted') {
           updatedUrls.splice(urlIndex, 1);
         } else if (update.data) {
-          updatedUrls[urlIndex] = { ...updatedUrls[urlIndex], ...update.data };
+          updatedUrls[urlIndex] = {
+            ...updatedUrls[urlIndex],
+            ...update.data,
+            progress: undefined,
+          };
         } else {
           updatedUrls[urlIndex] = {
             ...updatedUrls[urlIndex],
             status: update.status,
+            progress: undefined,
           };
         }
       }
@@ -82,8 +102,37 @@ export const useURLs = (options: UseURLsOptions = {}) => {
     });
   }, []);
 
+  const handleJobProgress = useCallback((update: JobProgressUpdate) => {
+    setUrls((currentUrls) =>
+      currentUrls.map((url): URLAnalysis =>
+        url.id === update.url_id
+          ? { ...url, status: 'processing', progress: update.data.progress }
+          : url
+      )
+    );
+  }, []);
+
+  const handleBatchFinished = useCallback(
+    (update: BatchFinishedUpdate) => {
+      const { succeeded, failed, cancelled } = update.data.summary;
+      const message = t('messages.bulkAnalyzeFinished', {
+        ok: succeeded,
+        failed: failed + cancelled,
+      });
+
+      if (failed + cancelled > 0) {
+        toast.error(message);
+      } else {
+        toast.success(message);
+      }
+    },
+    [t]
+  );
+
   const { isConnected } = useWebSocket({
     onStatusUpdate: handleStatusUpdate,
+    onJobProgress: handleJobProgress,
+    onBatchFinished: handleBatchFinished,
   });
 
   const fetchURLs = useCallback(async () => {
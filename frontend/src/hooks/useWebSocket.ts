@@ -7,16 +7,64 @@ interface StatusUpdate {
   data?: any;
 }
 
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
+
+export interface JobProgressUpdate {
+  type: 'job_progress';
+  url_id: number;
+  status: string;
+  data: {
+    job_id: string;
+    job_type: string;
+    progress: {
+      phase: string;
+      done?: number;
+      total?: number;
+      queued?: number;
+      message?: string;
+      updated_at: string;
+    };
+  };
+}
+
+export interface BatchFinishedUpdate {
+  type: 'batch_finished';
+  url_id: number;
+  status: string;
+  data: {
+    batch_id: string;
+    summary: {
+      total: number;
+      succeeded: number;
+      failed: number;
+      cancelled: number;
+      interrupted: number;
+      forwarded: number;
+    };
+    failed_urls: number[];
+    message: string;
+  };
+}
 
 interface UseWebSocketProps {
   onStatusUpdate?: (update: StatusUpdate) => void;
+  onJobProgress?: (update: JobProgressUpdate) => void;
+  onBatchFinished?: (update: BatchFinishedUpdate) => void;
   reconnectInterval?: number;
   maxReconnectAttempts?: number;
 }
 
 export const useWebSocket = ({
   onStatusUpdate,
+  onJobProgress,
+  onBatchFinished,
   reconnectInterval = 5000,
   maxReconnectAttempts = 5,
 }: UseWebSocketProps = {}) => {
@@ -41,10 +89,14 @@ export const useWebSocket = ({
 
       ws.onmessage = (event) => {
         try {
-          const update: StatusUpdate = JSON.parse(event.data);
+          const update = JSON.parse(event.data);
 
           if (onStatusUpdate && update.type === 'status_update') {
-            onStatusUpdate(update);
+            onStatusUpdate(update as StatusUpdate);
+          } else if (onJobProgress && update.type === 'job_progress') {
+            onJobProgress(update as JobProgressUpdate);
+          } else if (onBatchFinished && update.type === 'batch_finished') {
+            onBatchFinished(update as BatchFinishedUpdate);
           }
         } catch (err) {
           console.error('Error parsing WebSocket message:', err);
@@ -303,6 +303,9 @@ const DashboardPage: React.FC = () => {
       { value: 'processing', label: t('dashboard.filters.processing') },
       { value: 'completed', label: t('dashboard.filters.completed') },
       { value: 'error', label: t('dashboard.filters.error') },
+      { value: 'not_html', label: t('dashboard.filters.notHtml') },
+      { value: 'cancelled', label: t('dashboard.filters.cancelled') },
+      { value: 'purged', label: t('dashboard.filters.purged') },
     ],
     [t]
   );
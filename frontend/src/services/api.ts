@@ -15,7 +15,14 @@ export interface BrokenLink {
   error_message?: string;
 }
 
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
 
 export interface URLAnalysis {
   id: number;
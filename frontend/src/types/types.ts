@@ -1,6 +1,13 @@
 import React from 'react';
 
-export type URLStatus = 'queued' | 'processing' | 'completed' | 'error';
+export type URLStatus =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'error'
+  | 'not_html'
+  | 'cancelled'
+  | 'purged';
 
 export interface HeadingCounts {
   h1: number;
@@ -11,11 +18,23 @@ export interface HeadingCounts {
   h6: number;
 }
 
+export interface JobProgress {
+  phase: string;
+  done?: number;
+  total?: number;
+  queued?: number;
+  message?: string;
+  updated_at: string;
+}
+
 export interface URLAnalysis {
   id: number;
   url: string;
   title?: string;
   html_version?: string;
+  charset?: string;
+  content_type?: string;
+  content_length?: number;
   h1_count: number;
   h2_count: number;
   h3_count: number;
@@ -26,8 +45,12 @@ export interface URLAnalysis {
   external_links_count: number;
   broken_links_count: number;
   has_login_form: boolean;
+  content_hash?: string;
+  content_simhash?: string;
+  content_changed?: boolean;
   status: URLStatus;
   error_message?: string;
+  progress?: JobProgress;
   created_at: string;
   updated_at: string;
 }